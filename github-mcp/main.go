@@ -39,6 +39,24 @@ type CommentReqOrchestrator struct {
 	BodyFormat string     `json:"body_format"` // Optional
 }
 
+// ReviewComment anchors a single finding to a line in the PR diff.
+type ReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+// CommentReqReview is the action: "review_pr" payload, used to post
+// inline per-line comments via the Pull Request Reviews API.
+type CommentReqReview struct {
+	Action       string          `json:"action"`
+	PR           PRMetadata      `json:"pr"`
+	Event        string          `json:"event"`
+	Comments     []ReviewComment `json:"comments"`
+	FallbackBody string          `json:"fallback_body,omitempty"`
+}
+
 func mustEnv(key string) string {
 	val := os.Getenv(key)
 	if val == "" {
@@ -95,6 +113,38 @@ func main() {
 		raw := c.Body()
 		log.Printf("\n====== [MCP] RAW incoming JSON ======\n%s\n", string(raw))
 
+		var action struct {
+			Action string `json:"action"`
+		}
+		_ = json.Unmarshal(raw, &action)
+
+		if action.Action == "review_pr" {
+			var rv CommentReqReview
+			if err := json.Unmarshal(raw, &rv); err != nil {
+				log.Println("[ERROR] ❌ Could not parse body as CommentReqReview")
+				return fiber.NewError(fiber.StatusBadRequest, "invalid JSON format")
+			}
+			log.Println("[MCP] ✅ Parsed as REVIEW format")
+
+			owner := rv.PR.RepoOwner
+			repo := rv.PR.RepoName
+			pr := rv.PR.PRNumber
+
+			if owner == "" {
+				owner = defaultOwner
+			}
+			if repo == "" {
+				repo = defaultRepo
+			}
+
+			if owner == "" || repo == "" {
+				log.Println("[ERROR] Missing repo_owner or repo_name")
+				return fiber.NewError(fiber.StatusBadRequest, "missing repo_owner or repo_name")
+			}
+
+			return postReview(ctx, gh, owner, repo, pr, rv, c)
+		}
+
 		// Try ORCHESTRATOR model first (most common)
 		var o CommentReqOrchestrator
 		if err := json.Unmarshal(raw, &o); err == nil && o.PR.PRNumber != 0 {
@@ -149,6 +199,53 @@ func main() {
 	}
 }
 
+func postReview(ctx context.Context, gh *github.Client, owner, repo string, pr int, rv CommentReqReview, c *fiber.Ctx) error {
+	log.Printf("[MCP] → Posting review to GitHub PR #%d in %s/%s (%d comments)", pr, owner, repo, len(rv.Comments))
+
+	ghComments := make([]*github.DraftReviewComment, 0, len(rv.Comments))
+	for _, rc := range rv.Comments {
+		side := rc.Side
+		if side == "" {
+			side = "RIGHT"
+		}
+		ghComments = append(ghComments, &github.DraftReviewComment{
+			Path: github.String(rc.Path),
+			Line: github.Int(rc.Line),
+			Side: github.String(side),
+			Body: github.String(rc.Body),
+		})
+	}
+
+	event := rv.Event
+	if event == "" {
+		event = "COMMENT"
+	}
+
+	review := &github.PullRequestReviewRequest{
+		Event:    github.String(event),
+		Comments: ghComments,
+	}
+
+	created, resp, err := gh.PullRequests.CreateReview(ctx, owner, repo, pr, review)
+	if err != nil {
+		if resp != nil {
+			log.Printf("[ERROR] GitHub Status: %d", resp.StatusCode)
+		}
+		log.Printf("[ERROR] GitHub review error: %v, falling back to summary comment", err)
+		if rv.FallbackBody != "" {
+			return postComment(ctx, gh, owner, repo, pr, rv.FallbackBody, c)
+		}
+		return fiber.NewError(fiber.StatusBadGateway, err.Error())
+	}
+
+	log.Printf("[MCP] ✅ GitHub review OK: ReviewID=%d", created.GetID())
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"review_id": created.GetID(),
+	})
+}
+
 func postComment(ctx context.Context, gh *github.Client, owner, repo string, pr int, body string, c *fiber.Ctx) error {
 	log.Printf("[MCP] → Posting to GitHub PR #%d in %s/%s", pr, owner, repo)
 	log.Printf("[MCP] Body preview:\n%s\n", truncate(body, 400))
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
+
+	"github.com/rs/zerolog"
+)
+
+// codeQLScanner is a Scanner that doesn't run anything itself: CodeQL scans
+// run as their own GitHub Actions workflow and land in GitHub's
+// code-scanning API, so this just reads back whatever's already there for
+// the PR's head SHA rather than re-running analysis.
+type codeQLScanner struct {
+	apiBase string // defaults to https://api.github.com
+	token   string
+	log     zerolog.Logger
+	client  *httpx.Client
+}
+
+func (s *codeQLScanner) Name() string { return "codeql" }
+
+// codeQLAlert is the subset of GitHub's code-scanning alert object this
+// scanner uses. See
+// https://docs.github.com/en/rest/code-scanning/code-scanning#list-code-scanning-alerts-for-a-repository
+type codeQLAlert struct {
+	Number int    `json:"number"`
+	State  string `json:"state"` // "open", "dismissed", "fixed"
+	Rule   struct {
+		ID               string `json:"id"`
+		SecuritySeverity string `json:"security_severity_level"` // "critical", "high", "medium", "low"
+	} `json:"rule"`
+	MostRecentInstance struct {
+		Message struct {
+			Text string `json:"text"`
+		} `json:"message"`
+		Location struct {
+			Path      string `json:"path"`
+			StartLine int    `json:"start_line"`
+		} `json:"location"`
+	} `json:"most_recent_instance"`
+	HTMLURL string `json:"html_url"`
+}
+
+func (s *codeQLScanner) Scan(ctx context.Context, meta intm.PRMetadata) (*intm.SemgrepOut, error) {
+	apiBase := s.apiBase
+	if apiBase == "" {
+		apiBase = "https://api.github.com"
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/code-scanning/alerts?ref=%s&state=open&tool_name=CodeQL",
+		apiBase, meta.RepoOwner, meta.RepoName, meta.HeadSHA)
+
+	resp, err := s.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create codeql alerts request: %w", err)
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+s.token)
+		return req, nil
+	})
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to fetch codeql alerts")
+		return &intm.SemgrepOut{}, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Code scanning isn't enabled for this repo, or CodeQL hasn't run
+		// yet -- not an error, just nothing to report.
+		return &intm.SemgrepOut{}, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read codeql alerts body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		s.log.Error().Int("status", resp.StatusCode).Str("body", string(body)).Msg("codeql alerts request failed")
+		return &intm.SemgrepOut{}, nil
+	}
+
+	var alerts []codeQLAlert
+	if err := json.Unmarshal(body, &alerts); err != nil {
+		return nil, fmt.Errorf("decode codeql alerts: %w", err)
+	}
+
+	out := &intm.SemgrepOut{}
+	var md strings.Builder
+	for _, a := range alerts {
+		sev := codeQLSecuritySeverityToSeverity(a.Rule.SecuritySeverity)
+		bumpSeverity(&out.Severity, sev)
+
+		out.Findings = append(out.Findings, intm.Finding{
+			RuleID:   a.Rule.ID,
+			Path:     a.MostRecentInstance.Location.Path,
+			Line:     a.MostRecentInstance.Location.StartLine,
+			Severity: sev,
+			Message:  a.MostRecentInstance.Message.Text,
+		})
+		fmt.Fprintf(&md, "- **%s** `%s:%d` (%s): %s ([alert #%d](%s))\n",
+			a.Rule.ID, a.MostRecentInstance.Location.Path, a.MostRecentInstance.Location.StartLine,
+			sev, a.MostRecentInstance.Message.Text, a.Number, a.HTMLURL)
+	}
+	if md.Len() == 0 {
+		md.WriteString("No findings.\n")
+	}
+	out.FindingsMarkdown = md.String()
+
+	return out, nil
+}
+
+// codeQLSecuritySeverityToSeverity maps GitHub's security_severity_level
+// onto this repo's five-bucket severity scale.
+func codeQLSecuritySeverityToSeverity(level string) string {
+	switch level {
+	case "critical":
+		return "blocker"
+	case "high":
+		return "critical"
+	case "medium":
+		return "major"
+	case "low":
+		return "minor"
+	default:
+		return "info"
+	}
+}
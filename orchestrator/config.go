@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	intm "orchestrator/internal"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+)
+
+// configPath is the -c/--config flag: a YAML, TOML, or JSON file
+// (extension picks the format) holding the nested config schema below.
+// It's optional; every field also has an env var fallback via
+// loadConfigFromEnv, matched through viper's AutomaticEnv.
+var configPath = flag.String("config", "", "path to a YAML/TOML/JSON config file")
+
+func init() {
+	flag.StringVar(configPath, "c", "", "shorthand for --config")
+}
+
+// newViper builds a viper instance seeded with every current env-var
+// default (so a deployment with no config file behaves exactly as before)
+// and bound to the nested keys the file format introduces:
+// agents.pr_agent.url, agents.semgrep.url, agents.summarizer.url,
+// agents.github_mcp.url, http.timeout_minutes, platforms.
+func newViper(envDefaults intm.Config) *viper.Viper {
+	v := viper.New()
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	v.SetDefault("listen_addr", envDefaults.ListenAddr)
+	v.SetDefault("log_level", envDefaults.LogLevel)
+	v.SetDefault("http.timeout_minutes", envDefaults.HTTPTimeoutMinutes)
+	v.SetDefault("agents.pr_agent.url", envDefaults.PRAgentURL)
+	v.SetDefault("agents.semgrep.url", envDefaults.SemgrepServiceURL)
+	v.SetDefault("agents.summarizer.url", envDefaults.SummarizerURL)
+	v.SetDefault("agents.github_mcp.url", envDefaults.GitHubMCPURL)
+	v.SetDefault("github_token", envDefaults.GitHubToken)
+	v.SetDefault("github_webhook_secret", envDefaults.GitHubWebhookSecret)
+	v.SetDefault("disable_sarif_upload", envDefaults.DisableSarifUpload)
+	v.SetDefault("full_scan", envDefaults.FullScan)
+	v.SetDefault("scanners", envDefaults.EnabledScanners)
+	v.SetDefault("scanner_urls", envDefaults.ScannerURLs)
+	v.SetDefault("scanner_kind", envDefaults.ScannerKind)
+
+	v.SetDefault("gitlab.url", envDefaults.GitLabURL)
+	v.SetDefault("gitlab.token", envDefaults.GitLabToken)
+	v.SetDefault("gitlab.webhook_secret", envDefaults.GitLabWebhookSecret)
+	v.SetDefault("bitbucket.url", envDefaults.BitbucketURL)
+	v.SetDefault("bitbucket.username", envDefaults.BitbucketUsername)
+	v.SetDefault("bitbucket.app_password", envDefaults.BitbucketAppPassword)
+	v.SetDefault("bitbucket.webhook_secret", envDefaults.BitbucketWebhookSecret)
+	v.SetDefault("gitea.url", envDefaults.GiteaURL)
+	v.SetDefault("gitea.token", envDefaults.GiteaToken)
+	v.SetDefault("gitea.webhook_secret", envDefaults.GiteaWebhookSecret)
+
+	v.SetDefault("review_store.driver", envDefaults.ReviewStoreDriver)
+	v.SetDefault("review_store.dsn", envDefaults.ReviewStoreDSN)
+
+	v.SetDefault("public_url", envDefaults.PublicURL)
+
+	return v
+}
+
+// configFromViper reads every key newViper seeded back out, applying
+// whatever a config file or an AutomaticEnv-matched env var overrode.
+func configFromViper(v *viper.Viper) intm.Config {
+	var platforms []intm.PlatformConfig
+	if err := v.UnmarshalKey("platforms", &platforms); err != nil {
+		platforms = nil
+	}
+
+	// SCANNERS matched through AutomaticEnv arrives as one comma-separated
+	// string, which GetStringSlice splits only on whitespace.
+	var scanners []string
+	for _, entry := range v.GetStringSlice("scanners") {
+		for _, name := range strings.Split(entry, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				scanners = append(scanners, name)
+			}
+		}
+	}
+
+	return intm.Config{
+		ListenAddr:          v.GetString("listen_addr"),
+		LogLevel:            v.GetString("log_level"),
+		HTTPTimeoutMinutes:  v.GetInt("http.timeout_minutes"),
+		PRAgentURL:          v.GetString("agents.pr_agent.url"),
+		SemgrepServiceURL:   v.GetString("agents.semgrep.url"),
+		SummarizerURL:       v.GetString("agents.summarizer.url"),
+		GitHubMCPURL:        v.GetString("agents.github_mcp.url"),
+		GitHubToken:         v.GetString("github_token"),
+		GitHubWebhookSecret: v.GetString("github_webhook_secret"),
+		DisableSarifUpload:  v.GetBool("disable_sarif_upload"),
+		FullScan:            v.GetBool("full_scan"),
+		EnabledScanners:     scanners,
+		ScannerURLs:         v.GetStringMapString("scanner_urls"),
+		ScannerKind:         v.GetStringMapString("scanner_kind"),
+
+		GitLabURL:           v.GetString("gitlab.url"),
+		GitLabToken:         v.GetString("gitlab.token"),
+		GitLabWebhookSecret: v.GetString("gitlab.webhook_secret"),
+
+		BitbucketURL:           v.GetString("bitbucket.url"),
+		BitbucketUsername:      v.GetString("bitbucket.username"),
+		BitbucketAppPassword:   v.GetString("bitbucket.app_password"),
+		BitbucketWebhookSecret: v.GetString("bitbucket.webhook_secret"),
+
+		GiteaURL:           v.GetString("gitea.url"),
+		GiteaToken:         v.GetString("gitea.token"),
+		GiteaWebhookSecret: v.GetString("gitea.webhook_secret"),
+
+		Platforms: platforms,
+
+		ReviewStoreDriver: v.GetString("review_store.driver"),
+		ReviewStoreDSN:    v.GetString("review_store.dsn"),
+
+		PublicURL: v.GetString("public_url"),
+	}
+}
+
+// loadConfig builds the initial Config from, in increasing priority:
+// hardcoded defaults, the env vars loadConfigFromEnv already reads, and
+// (if -c/--config was given) a YAML/TOML/JSON file. An env var whose name
+// is the key upper-cased with dots as underscores (GITHUB_TOKEN for
+// github_token, GITLAB_URL for gitlab.url) is matched by AutomaticEnv and
+// overrides the file for that key. v is returned so watchConfigFile can
+// re-read the same file on change.
+func loadConfig() (intm.Config, *viper.Viper) {
+	v := newViper(loadConfigFromEnv())
+
+	if *configPath != "" {
+		v.SetConfigFile(*configPath)
+		_ = v.ReadInConfig() // missing/invalid file just falls back to defaults+env
+	}
+
+	return configFromViper(v), v
+}
+
+// watchConfigFile watches configPath's directory (not the file itself --
+// editors and orchestration tools like Kubernetes ConfigMap reloaders
+// replace the file via rename rather than writing in place, which a
+// direct file watch would miss) and atomically swaps store's value on
+// every change, so PRAgentURL/SummarizerURL and other agent URL edits take
+// effect without restarting the orchestrator.
+func watchConfigFile(v *viper.Viper, store *atomic.Value, log zerolog.Logger) {
+	if *configPath == "" {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to start config file watcher, hot reload disabled")
+		return
+	}
+
+	dir := filepath.Dir(*configPath)
+	target := filepath.Clean(*configPath)
+	if err := watcher.Add(dir); err != nil {
+		log.Warn().Err(err).Str("dir", dir).Msg("failed to watch config directory, hot reload disabled")
+		watcher.Close()
+		return
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != target {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+
+				if err := v.ReadInConfig(); err != nil {
+					log.Warn().Err(err).Msg("config file changed but failed to reload, keeping previous config")
+					continue
+				}
+				store.Store(configFromViper(v))
+				log.Info().Str("path", *configPath).Msg("config file reloaded")
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warn().Err(err).Msg("config file watcher error")
+			}
+		}
+	}()
+}
@@ -0,0 +1,135 @@
+// Package actions emits GitHub Actions workflow commands to stdout so
+// Semgrep findings show up as annotations on the "Files changed" tab and
+// in the job summary, instead of only in the PR comment. All functions
+// are no-ops unless GITHUB_ACTIONS=true is set.
+package actions
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	intm "orchestrator/internal"
+)
+
+// Enabled reports whether the orchestrator is running inside a GitHub
+// Actions job.
+func Enabled() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// Group opens a collapsible log group. Must be paired with EndGroup.
+func Group(name string) {
+	if !Enabled() {
+		return
+	}
+	fmt.Printf("::group::%s\n", name)
+}
+
+// EndGroup closes the most recently opened group.
+func EndGroup() {
+	if !Enabled() {
+		return
+	}
+	fmt.Println("::endgroup::")
+}
+
+// Mask tells the Actions runner to redact value from subsequent log output.
+func Mask(value string) {
+	if !Enabled() || value == "" {
+		return
+	}
+	fmt.Printf("::add-mask::%s\n", value)
+}
+
+// tokenPattern catches the common PAT/secret shapes that might leak into a
+// PR body (GitHub PATs, generic Bearer tokens).
+var tokenPattern = regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{20,}|Bearer\s+[A-Za-z0-9\-._~+/]{20,}`)
+
+// MaskSecretsInText scans text for token-shaped substrings and masks each
+// one found, so they never show up in subsequent annotation output.
+func MaskSecretsInText(text string) {
+	if !Enabled() {
+		return
+	}
+	for _, m := range tokenPattern.FindAllString(text, -1) {
+		Mask(m)
+	}
+}
+
+// EmitFinding writes a single ::error/::warning/::notice workflow command
+// for a Semgrep finding, escaped per the workflow-command spec.
+func EmitFinding(f intm.Finding) {
+	if !Enabled() {
+		return
+	}
+
+	level := annotationLevel(f.Severity)
+	fmt.Printf(
+		"::%s file=%s,line=%d,title=%s::%s\n",
+		level,
+		escapeProperty(f.Path),
+		f.Line,
+		escapeProperty(f.RuleID),
+		escapeData(f.Message),
+	)
+}
+
+// EmitFindings emits one annotation per finding.
+func EmitFindings(findings []intm.Finding) {
+	for _, f := range findings {
+		EmitFinding(f)
+	}
+}
+
+func annotationLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "blocker", "critical", "error":
+		return "error"
+	case "major", "warning":
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// WriteStepSummary appends markdown to the file pointed to by
+// GITHUB_STEP_SUMMARY, rendered on the job summary page.
+func WriteStepSummary(markdown string) error {
+	if !Enabled() {
+		return nil
+	}
+
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open GITHUB_STEP_SUMMARY: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(markdown + "\n"); err != nil {
+		return fmt.Errorf("write GITHUB_STEP_SUMMARY: %w", err)
+	}
+	return nil
+}
+
+// escapeData escapes a workflow command's message payload.
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	s = strings.ReplaceAll(s, "\n", "%0A")
+	return s
+}
+
+// escapeProperty escapes a workflow command property value (file, title, ...).
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	s = strings.ReplaceAll(s, ",", "%2C")
+	return s
+}
@@ -0,0 +1,133 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorClass buckets a downstream failure so callers (and the retry loop
+// in Do) can tell "try again" apart from "stop now" without
+// re-implementing status-code judgment calls at every call site.
+type ErrorClass string
+
+const (
+	// ClassTransient covers network errors and 5xx/529 responses: worth
+	// retrying with backoff.
+	ClassTransient ErrorClass = "transient"
+	// ClassRateLimited covers 429 and Anthropic's rate_limit_error:
+	// retryable, but the wait should honor Retry-After when present.
+	ClassRateLimited ErrorClass = "rate_limited"
+	// ClassAuth covers 401/403 and Anthropic's authentication_error /
+	// permission_error: retrying won't help without operator action.
+	ClassAuth ErrorClass = "auth"
+	// ClassPermanent covers other 4xx and Anthropic's invalid_request_error:
+	// the request itself is bad, so retrying just repeats the mistake.
+	ClassPermanent ErrorClass = "permanent"
+)
+
+// Sentinel errors so callers can use errors.Is without reaching into a
+// ClassifiedError.
+var (
+	ErrTransient   = errors.New("httpx: transient error")
+	ErrRateLimited = errors.New("httpx: rate limited")
+	ErrAuth        = errors.New("httpx: authentication error")
+	ErrPermanent   = errors.New("httpx: permanent error")
+)
+
+func (c ErrorClass) sentinel() error {
+	switch c {
+	case ClassRateLimited:
+		return ErrRateLimited
+	case ClassAuth:
+		return ErrAuth
+	case ClassPermanent:
+		return ErrPermanent
+	default:
+		return ErrTransient
+	}
+}
+
+// Retryable reports whether Do should attempt this call again.
+func (c ErrorClass) Retryable() bool {
+	return c == ClassTransient || c == ClassRateLimited
+}
+
+// ClassifiedError wraps a downstream HTTP failure with its ErrorClass, so
+// errors.Is(err, httpx.ErrAuth) works all the way up through CallSummarizer
+// etc. without those callers needing to know about status codes.
+type ClassifiedError struct {
+	Class  ErrorClass
+	Status int
+	Host   string
+	Detail string // Anthropic's error.message, when the body parsed as its envelope
+}
+
+func (e *ClassifiedError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("httpx: %s (status %d from %s): %s", e.Class, e.Status, e.Host, e.Detail)
+	}
+	return fmt.Sprintf("httpx: %s (status %d from %s)", e.Class, e.Status, e.Host)
+}
+
+func (e *ClassifiedError) Unwrap() error { return e.Class.sentinel() }
+
+// anthropicErrorEnvelope mirrors the {"type":"error","error":{"type":...}}
+// body Anthropic returns on non-2xx responses.
+type anthropicErrorEnvelope struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// classifyAnthropicType maps Anthropic's error.type field onto an
+// ErrorClass. See https://docs.anthropic.com/en/api/errors.
+func classifyAnthropicType(errType string) (ErrorClass, bool) {
+	switch errType {
+	case "rate_limit_error":
+		return ClassRateLimited, true
+	case "authentication_error", "permission_error":
+		return ClassAuth, true
+	case "invalid_request_error", "not_found_error", "request_too_large":
+		return ClassPermanent, true
+	case "overloaded_error", "api_error", "timeout_error":
+		return ClassTransient, true
+	default:
+		return "", false
+	}
+}
+
+// classifyStatus is the fallback when the body isn't a recognized error
+// envelope: judge purely from the HTTP status code.
+func classifyStatus(status int) ErrorClass {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ClassRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ClassAuth
+	case status == 529: // Anthropic's "overloaded" status
+		return ClassTransient
+	case status >= 500:
+		return ClassTransient
+	default:
+		return ClassPermanent
+	}
+}
+
+// Classify inspects a non-2xx response's status and body and returns the
+// ClassifiedError to surface to the caller. Body sniffing takes priority
+// over the status-code fallback since a 400 carrying
+// {"error":{"type":"rate_limit_error"}} (as Anthropic does for some
+// overload conditions) should still be treated as retryable.
+func Classify(host string, status int, body []byte) *ClassifiedError {
+	var envelope anthropicErrorEnvelope
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error.Type != "" {
+		if class, ok := classifyAnthropicType(envelope.Error.Type); ok {
+			return &ClassifiedError{Class: class, Status: status, Host: host, Detail: envelope.Error.Message}
+		}
+	}
+	return &ClassifiedError{Class: classifyStatus(status), Status: status, Host: host}
+}
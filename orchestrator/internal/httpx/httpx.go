@@ -0,0 +1,224 @@
+// Package httpx wraps an *http.Client with exponential backoff retries,
+// classified errors, and a per-host circuit breaker, so a single flaky
+// dependency (GitHub, the Semgrep service) doesn't stall every PR webhook.
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sony/gobreaker"
+)
+
+// ErrCircuitOpen is returned when a host's breaker is open and the call
+// fails fast instead of being attempted.
+var ErrCircuitOpen = errors.New("httpx: circuit breaker open")
+
+// Client retries transient/rate-limited failures (network errors, 5xx,
+// 429) with exponential backoff and jitter, and trips a per-host
+// sony/gobreaker circuit breaker after repeated failures.
+type Client struct {
+	HTTPClient *http.Client
+	Log        zerolog.Logger
+
+	InitialBackoff time.Duration // default 500ms
+	MaxBackoff     time.Duration // default 30s
+	MaxElapsedTime time.Duration // default 2m, caps total retry time
+	FailuresToOpen int           // default 5
+	OpenCooldown   time.Duration // default 30s
+
+	breakers sync.Map // host -> *gobreaker.CircuitBreaker
+}
+
+func NewClient(httpClient *http.Client, log zerolog.Logger) *Client {
+	return &Client{
+		HTTPClient:     httpClient,
+		Log:            log,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		MaxElapsedTime: 2 * time.Minute,
+		FailuresToOpen: 5,
+		OpenCooldown:   30 * time.Second,
+	}
+}
+
+func (c *Client) breakerFor(host string) *gobreaker.CircuitBreaker {
+	if b, ok := c.breakers.Load(host); ok {
+		return b.(*gobreaker.CircuitBreaker)
+	}
+
+	failuresToOpen := uint32(c.FailuresToOpen)
+	b := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        host,
+		MaxRequests: 1, // only one probe allowed through while half-open
+		Timeout:     c.OpenCooldown,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= failuresToOpen
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			c.Log.Warn().Str("host", name).Str("from", from.String()).Str("to", to.String()).Msg("httpx: circuit breaker state change")
+		},
+	})
+	actual, _ := c.breakers.LoadOrStore(host, b)
+	return actual.(*gobreaker.CircuitBreaker)
+}
+
+// attemptResult is what each breaker-wrapped attempt returns through
+// gobreaker.Execute's interface{} slot.
+type attemptResult struct {
+	resp *http.Response
+}
+
+// Do executes newReq with retries and circuit breaking. newReq must build
+// a fresh request (and body) on every call, since a failed attempt may
+// have already consumed a streaming body. The returned error is an
+// ErrCircuitOpen-wrapping error, a *ClassifiedError (see errors.go), or a
+// plain network error -- callers that care about the failure kind should
+// use errors.As/errors.Is rather than string matching.
+func (c *Client) Do(ctx context.Context, newReq func(context.Context) (*http.Request, error)) (*http.Response, error) {
+	start := time.Now()
+	deadline := start.Add(c.maxElapsed())
+	backoffDur := c.initialBackoff()
+
+	var (
+		lastErr error
+		host    string
+	)
+	for attempt := 1; ; attempt++ {
+		req, err := newReq(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if host == "" {
+			host = req.URL.Hostname()
+		}
+		breaker := c.breakerFor(host)
+
+		c.Log.Debug().Str("host", host).Int("attempt", attempt).Msg("httpx: attempting request")
+
+		var retryAfterHint time.Duration
+		result, execErr := breaker.Execute(func() (interface{}, error) {
+			resp, err := c.HTTPClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			if !isSuccess(resp.StatusCode) {
+				retryAfterHint = retryAfter(resp)
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				return nil, Classify(host, resp.StatusCode, body)
+			}
+			return &attemptResult{resp: resp}, nil
+		})
+
+		if execErr == nil {
+			observeOutcome(host, "success", start)
+			return result.(*attemptResult).resp, nil
+		}
+
+		if errors.Is(execErr, gobreaker.ErrOpenState) || errors.Is(execErr, gobreaker.ErrTooManyRequests) {
+			c.Log.Warn().Str("host", host).Msg("httpx: circuit open, failing fast")
+			observeOutcome(host, "circuit_open", start)
+			return nil, fmt.Errorf("%w: host=%s", ErrCircuitOpen, host)
+		}
+
+		lastErr = execErr
+		var classified *ClassifiedError
+		wait := backoffDur
+		outcome := "transient_error"
+		if errors.As(execErr, &classified) {
+			outcome = string(classified.Class) + "_error"
+			if !classified.Class.Retryable() {
+				c.Log.Warn().Err(execErr).Str("host", host).Int("attempt", attempt).Msg("httpx: non-retryable error, giving up")
+				observeOutcome(host, outcome, start)
+				return nil, execErr
+			}
+			if retryAfterHint > 0 {
+				wait = retryAfterHint
+			}
+			c.Log.Warn().Err(execErr).Str("host", host).Int("attempt", attempt).Msg("httpx: retryable error, will retry")
+		} else {
+			c.Log.Warn().Err(execErr).Str("host", host).Int("attempt", attempt).Msg("httpx: network error, will retry")
+		}
+
+		if time.Now().Add(wait).After(deadline) {
+			c.Log.Error().Err(lastErr).Str("host", host).Int("attempts", attempt).Msg("httpx: max elapsed time exceeded, giving up")
+			observeOutcome(host, outcome, start)
+			return nil, lastErr
+		}
+
+		select {
+		case <-ctx.Done():
+			observeOutcome(host, "context_canceled", start)
+			return nil, ctx.Err()
+		case <-time.After(jitter(wait)):
+		}
+
+		backoffDur *= 2
+		if backoffDur > c.maxBackoff() {
+			backoffDur = c.maxBackoff()
+		}
+	}
+}
+
+func isSuccess(status int) bool {
+	return status >= 200 && status < 300
+}
+
+func (c *Client) initialBackoff() time.Duration {
+	if c.InitialBackoff > 0 {
+		return c.InitialBackoff
+	}
+	return 500 * time.Millisecond
+}
+
+func (c *Client) maxBackoff() time.Duration {
+	if c.MaxBackoff > 0 {
+		return c.MaxBackoff
+	}
+	return 30 * time.Second
+}
+
+func (c *Client) maxElapsed() time.Duration {
+	if c.MaxElapsedTime > 0 {
+		return c.MaxElapsedTime
+	}
+	return 2 * time.Minute
+}
+
+// retryAfter reads the standard Retry-After header (seconds or an HTTP
+// date), used to override the exponential backoff wait for 429/529
+// responses that tell us exactly how long to back off.
+func retryAfter(resp *http.Response) time.Duration {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	// +/- 20% jitter to avoid synchronized retries across PRs.
+	delta := time.Duration(rand.Int63n(int64(d) / 5))
+	if rand.Intn(2) == 0 {
+		return d - delta
+	}
+	return d + delta
+}
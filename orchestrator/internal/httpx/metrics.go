@@ -0,0 +1,32 @@
+package httpx
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// These extend the loggingMiddleware pattern (orchestrator/main.go)
+// outward to egress: every Do call, successful or not, is counted and
+// timed per destination host.
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orchestrator_httpx_requests_total",
+		Help: "Outbound requests made through httpx.Client, by host and outcome.",
+	}, []string{"host", "outcome"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "orchestrator_httpx_request_duration_seconds",
+		Help: "Latency of httpx.Client.Do calls (including retries), by host.",
+	}, []string{"host"})
+)
+
+// outcome labels: "success", the ErrorClass of the final failure with an
+// "_error" suffix (network errors count as "transient_error"),
+// "circuit_open" when the breaker failed the call fast, or
+// "context_canceled" when ctx ended during a backoff wait.
+func observeOutcome(host, outcome string, start time.Time) {
+	requestsTotal.WithLabelValues(host, outcome).Inc()
+	requestDuration.WithLabelValues(host).Observe(time.Since(start).Seconds())
+}
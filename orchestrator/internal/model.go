@@ -1,5 +1,7 @@
 package internal
 
+import "encoding/json"
+
 type Config struct {
 	ListenAddr         string
 	LogLevel           string
@@ -8,6 +10,78 @@ type Config struct {
 	SummarizerURL      string
 	GitHubMCPURL       string
 	HTTPTimeoutMinutes int
+
+	GitHubToken         string // used for direct GitHub API calls (code-scanning uploads)
+	GitHubWebhookSecret string // verifies X-Hub-Signature-256; empty disables verification
+	DisableSarifUpload  bool   // set for forks where the token lacks security_events scope
+	FullScan            bool   // FULL_SCAN=true scans the whole repo instead of just the PR diff
+
+	// GitLab, Bitbucket, and Gitea integrations are only wired up when
+	// their URL is non-empty; see buildPlatforms.
+	GitLabURL           string
+	GitLabToken         string
+	GitLabWebhookSecret string
+
+	BitbucketURL           string
+	BitbucketUsername      string
+	BitbucketAppPassword   string
+	BitbucketWebhookSecret string
+
+	GiteaURL           string
+	GiteaToken         string
+	GiteaWebhookSecret string
+
+	// EnabledScanners lists the scanner backends to run for each PR, e.g.
+	// []string{"semgrep", "gosec", "gitleaks"}. Populated from SCANNERS.
+	EnabledScanners []string
+	// ScannerURLs maps a scanner name (as it appears in EnabledScanners) to
+	// the base URL of its service, e.g. SemgrepServiceURL for "semgrep".
+	ScannerURLs map[string]string
+	// ScannerKind maps a scanner name to the protocol BuildScanners should
+	// speak to it: "" or "http" (default) for the Semgrep-service protocol
+	// that semgrep/gosec/trivy/gitleaks all share, "sarif" for a tool that
+	// only exposes a prebuilt SARIF document at ScannerURLs[name] (e.g.
+	// Bandit, ESLint run as a separate CI step), or "codeql" to pull alerts
+	// for the PR's head SHA straight from GitHub's code-scanning API
+	// instead of a configured URL.
+	ScannerKind map[string]string
+
+	// Platforms is the declarative form of the per-provider fields above,
+	// populated from a config file's "platforms" list (see config.go). When
+	// non-empty it takes precedence over the GitHub/GitLab/Bitbucket/Gitea
+	// fields, which remain as the env-var-only fallback.
+	Platforms []PlatformConfig
+
+	// ReviewStoreDriver and ReviewStoreDSN configure the pkg/reviewapi
+	// persistence backend for the read-only /api/v1/reviews history. See
+	// reviewapi.NewStore; driver is "sqlite" (default) or "postgres".
+	ReviewStoreDriver string
+	ReviewStoreDSN    string
+
+	// PublicURL is this orchestrator's own externally-reachable base URL
+	// (e.g. https://orchestrator.example.com), used to build the
+	// progress-callback URL handed to the summarizer so it can stream
+	// partial markdown back into /api/v1/reviews/{id}/progress as it
+	// generates it. Left empty, no callback URL is sent and streaming
+	// progress is only checkpointed to disk, not surfaced over SSE.
+	PublicURL string
+}
+
+// PlatformConfig is one entry of a config file's "platforms" list, e.g.:
+//
+//	platforms:
+//	  - type: github
+//	    url: http://github-mcp-server:80/comment
+//	    secret: <webhook signing secret>
+//
+// Values are taken literally; ${VAR} references are not expanded.
+type PlatformConfig struct {
+	Type        string `mapstructure:"type"` // "github", "gitlab", "bitbucket", or "gitea"
+	URL         string `mapstructure:"url"`
+	Token       string `mapstructure:"token"`
+	Secret      string `mapstructure:"secret"` // webhook signing secret
+	Username    string `mapstructure:"username"`
+	AppPassword string `mapstructure:"app_password"`
 }
 
 type PRMetadata struct {
@@ -53,6 +127,37 @@ type SemgrepSeveritySummary struct {
 type SemgrepOut struct {
 	FindingsMarkdown string                 `json:"findings_markdown"`
 	Severity         SemgrepSeveritySummary `json:"severity"`
+	Sarif            string                 `json:"sarif,omitempty"` // full SARIF 2.1.0 document, if the scanner produced one
+	Findings         []Finding              `json:"findings,omitempty"`
+
+	// Reports carries the same result broken out per analyzer, so callers
+	// that care which tool said what (the summarizer's prompt builder) don't
+	// have to re-parse the merged FindingsMarkdown. Populated by
+	// mergeScanOutcomes; a single-scanner SemgrepOut (e.g. from an
+	// individual Scanner.Scan call) may leave it empty.
+	Reports []AnalyzerReport `json:"reports,omitempty"`
+}
+
+// AnalyzerReport is one scanner's contribution to a run: its own markdown
+// section, severity counts, and raw SARIF, kept separate from the other
+// analyzers' so the summarizer can render one section per tool and new
+// analyzers can be added without touching its prompt-building code.
+type AnalyzerReport struct {
+	Name        string                 `json:"name"`
+	ToolVersion string                 `json:"tool_version,omitempty"`
+	Markdown    string                 `json:"markdown"`
+	Severity    SemgrepSeveritySummary `json:"severity"`
+	Sarif       json.RawMessage        `json:"sarif,omitempty"`
+}
+
+// Finding is a single structured Semgrep result, used to anchor inline
+// review comments instead of only rendering a markdown summary.
+type Finding struct {
+	RuleID   string `json:"rule_id"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
 }
 
 type GitHubCommentRequest struct {
@@ -61,3 +166,20 @@ type GitHubCommentRequest struct {
 	Body       string     `json:"body"`
 	BodyFormat string     `json:"body_format"`
 }
+
+// ReviewComment anchors a single Semgrep finding to a line in the PR diff.
+type ReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"` // "LEFT" or "RIGHT"
+	Body string `json:"body"`
+}
+
+// GitHubReviewRequest is sent to the github-mcp-server's "review_pr" action.
+type GitHubReviewRequest struct {
+	Action       string          `json:"action"`
+	PR           PRMetadata      `json:"pr"`
+	Event        string          `json:"event"` // "COMMENT", "APPROVE", "REQUEST_CHANGES"
+	Comments     []ReviewComment `json:"comments"`
+	FallbackBody string          `json:"fallback_body,omitempty"` // used when a comment can't be anchored
+}
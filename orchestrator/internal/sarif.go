@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SarifUploadRequest is the body GitHub expects at
+// POST /repos/{owner}/{repo}/code-scanning/sarifs.
+type SarifUploadRequest struct {
+	CommitSHA    string `json:"commit_sha"`
+	Ref          string `json:"ref"`
+	Sarif        string `json:"sarif"` // gzip+base64 encoded SARIF document
+	ToolName     string `json:"tool_name,omitempty"`
+	CheckoutURI  string `json:"checkout_uri,omitempty"`
+	ValidateOnly bool   `json:"validate,omitempty"`
+}
+
+type sarifUploadAccepted struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+type sarifUploadStatus struct {
+	ProcessingStatus string   `json:"processing_status"`
+	Errors           []string `json:"errors,omitempty"`
+}
+
+// SarifUploader pushes a Semgrep SARIF document to GitHub's code-scanning
+// API and polls until GitHub has finished processing it.
+type SarifUploader struct {
+	Client   *http.Client
+	APIBase  string // defaults to https://api.github.com
+	Token    string
+	ToolName string // defaults to "semgrep"
+
+	PollInterval time.Duration // defaults to 3s
+	PollTimeout  time.Duration // defaults to 2m
+}
+
+func NewSarifUploader(client *http.Client, token string) *SarifUploader {
+	return &SarifUploader{
+		Client:       client,
+		APIBase:      "https://api.github.com",
+		Token:        token,
+		ToolName:     "semgrep",
+		PollInterval: 3 * time.Second,
+		PollTimeout:  2 * time.Minute,
+	}
+}
+
+// Upload gzip+base64 encodes sarifDoc and submits it for owner/repo at
+// commitSHA/ref, then polls the returned id until GitHub reports the
+// upload as processed (or the poll timeout elapses).
+func (u *SarifUploader) Upload(
+	ctx context.Context,
+	log zerolog.Logger,
+	owner, repo, commitSHA, ref string,
+	sarifDoc []byte,
+) error {
+	encoded, err := gzipBase64(sarifDoc)
+	if err != nil {
+		return fmt.Errorf("encode sarif: %w", err)
+	}
+
+	toolName := u.ToolName
+	if toolName == "" {
+		toolName = "semgrep"
+	}
+
+	payload := SarifUploadRequest{
+		CommitSHA: commitSHA,
+		Ref:       ref,
+		Sarif:     encoded,
+		ToolName:  toolName,
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(payload); err != nil {
+		return fmt.Errorf("encode sarif upload payload: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/code-scanning/sarifs", u.APIBase, owner, repo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if err != nil {
+		return fmt.Errorf("create sarif upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+u.Token)
+
+	log.Debug().
+		Str("owner", owner).
+		Str("repo", repo).
+		Str("ref", ref).
+		Msg("uploading SARIF to GitHub code-scanning")
+
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sarif upload http error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sarif upload returned status %d", resp.StatusCode)
+	}
+
+	var accepted sarifUploadAccepted
+	if err := json.NewDecoder(resp.Body).Decode(&accepted); err != nil {
+		return fmt.Errorf("decode sarif upload response: %w", err)
+	}
+
+	log.Info().Str("sarif_id", accepted.ID).Msg("SARIF upload accepted, polling for processing")
+
+	return u.pollUntilProcessed(ctx, log, owner, repo, accepted.ID)
+}
+
+func (u *SarifUploader) pollUntilProcessed(ctx context.Context, log zerolog.Logger, owner, repo, sarifID string) error {
+	interval := u.PollInterval
+	if interval <= 0 {
+		interval = 3 * time.Second
+	}
+	timeout := u.PollTimeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/repos/%s/%s/code-scanning/sarifs/%s", u.APIBase, owner, repo, sarifID)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := u.fetchStatus(pollCtx, url)
+		if err != nil {
+			return fmt.Errorf("poll sarif status: %w", err)
+		}
+
+		log.Debug().Str("status", status.ProcessingStatus).Msg("sarif processing status")
+
+		switch status.ProcessingStatus {
+		case "complete":
+			return nil
+		case "failed":
+			return fmt.Errorf("sarif processing failed: %v", status.Errors)
+		}
+
+		select {
+		case <-pollCtx.Done():
+			return fmt.Errorf("timed out waiting for sarif %s to process: %w", sarifID, pollCtx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *SarifUploader) fetchStatus(ctx context.Context, url string) (*sarifUploadStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+u.Token)
+
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status check returned %d", resp.StatusCode)
+	}
+
+	var status sarifUploadStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func gzipBase64(data []byte) (string, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
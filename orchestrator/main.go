@@ -1,26 +1,51 @@
 package main
 
 import (
+	"flag"
 	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
+	"orchestrator/pkg/reviewapi"
+	"orchestrator/platform"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 )
 
 type Orchestrator struct {
 	log                zerolog.Logger
-	cfg                intm.Config
+	cfgStore           atomic.Value // holds intm.Config; see config.go
 	httpClient         *http.Client
+	resilientClient    *httpx.Client
 	HTTPTimeoutMinutes int
+
+	// platforms maps a provider name ("github", "gitlab", ...) to its
+	// Platform, as registered under /webhook/{provider}.
+	platforms map[string]platform.Platform
+
+	// reviewRec persists every processPR run and serves it back under
+	// /api/v1/reviews; see pkg/reviewapi.
+	reviewRec *reviewapi.Recorder
+}
+
+// cfg returns the orchestrator's current config. It's a method rather than
+// a field so config.go's hot-reload watcher can swap the live value under
+// cfgStore without any caller needing to know about the atomic.Value.
+func (o *Orchestrator) cfg() intm.Config {
+	return o.cfgStore.Load().(intm.Config)
 }
 
 func main() {
-	cfg := loadConfigFromEnv()
+	flag.Parse()
+
+	cfg, v := loadConfig()
 	logger := intm.NewLogger(cfg.LogLevel)
 
 	// Parse HTTP timeout from config (in minutes)
@@ -39,23 +64,53 @@ func main() {
 		},
 	}
 
+	resilientClient := httpx.NewClient(httpClient, logger)
+
+	reviewStore, err := reviewapi.NewStore(cfg.ReviewStoreDriver, cfg.ReviewStoreDSN)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to open review history store")
+	}
+
 	oa := &Orchestrator{
-		log:        logger,
-		cfg:        cfg,
-		httpClient: httpClient,
+		log:             logger,
+		httpClient:      httpClient,
+		resilientClient: resilientClient,
+		// platforms is built once from the startup config: webhook secrets
+		// and provider tokens aren't expected to rotate as often as agent
+		// URLs, and re-registering mux routes at runtime isn't supported by
+		// net/http.ServeMux. The agent URLs below, by contrast, are read
+		// live through o.cfg() on every request.
+		platforms: buildPlatforms(cfg, resilientClient, logger),
+		reviewRec: reviewapi.NewRecorder(reviewStore, logger),
 	}
+	oa.cfgStore.Store(cfg)
+	watchConfigFile(v, &oa.cfgStore, logger)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", oa.healthHandler)
-	mux.Handle("/webhook", oa.loggingMiddleware(http.HandlerFunc(oa.prWebhookHandler)))
+	for name, plat := range oa.platforms {
+		mux.Handle("/webhook/"+name, oa.loggingMiddleware(oa.webhookHandler(plat)))
+	}
+	// /webhook is the original, GitHub-only route, kept for existing
+	// webhook configurations that predate multi-platform support.
+	if gh, ok := oa.platforms["github"]; ok {
+		mux.Handle("/webhook", oa.loggingMiddleware(oa.webhookHandler(gh)))
+	}
+	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", reviewapi.NewHandler(oa.reviewRec)))
+	// /metrics exposes the httpx egress counters/histograms (see
+	// internal/httpx/metrics.go) alongside the Go/process collectors
+	// promhttp registers by default.
+	mux.Handle("/metrics", promhttp.Handler())
 
 	logger.Info().
 		Str("addr", cfg.ListenAddr).
 		Str("pr_agent", cfg.PRAgentURL).
 		Str("semgrep_service", cfg.SemgrepServiceURL). // CHANGED: Now logs Semgrep service URL
+		Strs("scanners", cfg.EnabledScanners).
 		Str("summarizer", cfg.SummarizerURL).
 		Str("github_mcp", cfg.GitHubMCPURL).
 		Int("http_timeout_minutes", cfg.HTTPTimeoutMinutes).
+		Int("platforms", len(oa.platforms)).
 		Msg("starting orchestrator")
 
 	if err := http.ListenAndServe(cfg.ListenAddr, mux); err != nil {
@@ -63,6 +118,56 @@ func main() {
 	}
 }
 
+// buildPlatforms constructs one platform.Platform per configured forge.
+// If cfg.Platforms was populated from a config file's declarative
+// "platforms" list, that takes precedence; otherwise it falls back to the
+// env-var-only fields, with GitHub always registered (it has a default
+// GitHubMCPURL) and GitLab/Bitbucket/Gitea only when their URL is set, so
+// an unconfigured forge doesn't show up as a live but broken route.
+func buildPlatforms(cfg intm.Config, client *httpx.Client, log zerolog.Logger) map[string]platform.Platform {
+	if len(cfg.Platforms) > 0 {
+		return buildPlatformsFromList(cfg.Platforms, client, log)
+	}
+
+	platforms := map[string]platform.Platform{
+		"github": platform.NewGitHub(client, log, cfg.GitHubMCPURL, cfg.GitHubWebhookSecret),
+	}
+
+	if cfg.GitLabURL != "" {
+		platforms["gitlab"] = platform.NewGitLab(client, log, cfg.GitLabURL, cfg.GitLabToken, cfg.GitLabWebhookSecret)
+	}
+	if cfg.BitbucketURL != "" {
+		platforms["bitbucket"] = platform.NewBitbucket(client, log, cfg.BitbucketURL, cfg.BitbucketUsername, cfg.BitbucketAppPassword, cfg.BitbucketWebhookSecret)
+	}
+	if cfg.GiteaURL != "" {
+		platforms["gitea"] = platform.NewGitea(client, log, cfg.GiteaURL, cfg.GiteaToken, cfg.GiteaWebhookSecret)
+	}
+
+	return platforms
+}
+
+// buildPlatformsFromList constructs platforms from a config file's
+// "platforms" list. An entry with an unrecognized type is skipped with a
+// warning rather than failing startup.
+func buildPlatformsFromList(entries []intm.PlatformConfig, client *httpx.Client, log zerolog.Logger) map[string]platform.Platform {
+	platforms := make(map[string]platform.Platform, len(entries))
+	for _, e := range entries {
+		switch e.Type {
+		case "github":
+			platforms["github"] = platform.NewGitHub(client, log, e.URL, e.Secret)
+		case "gitlab":
+			platforms["gitlab"] = platform.NewGitLab(client, log, e.URL, e.Token, e.Secret)
+		case "bitbucket":
+			platforms["bitbucket"] = platform.NewBitbucket(client, log, e.URL, e.Username, e.AppPassword, e.Secret)
+		case "gitea":
+			platforms["gitea"] = platform.NewGitea(client, log, e.URL, e.Token, e.Secret)
+		default:
+			log.Warn().Str("type", e.Type).Msg("unknown platform type in config, skipping")
+		}
+	}
+	return platforms
+}
+
 func loadConfigFromEnv() intm.Config {
 	addr := os.Getenv("LISTEN_ADDR")
 	if addr == "" {
@@ -104,15 +209,89 @@ func loadConfigFromEnv() intm.Config {
 		githubMCPURL = "http://github-mcp-server:80/comment"
 	}
 
+	// Used to upload SARIF directly to GitHub's code-scanning API. Forks
+	// running with a token that lacks security_events scope should set
+	// DISABLE_SARIF_UPLOAD=true instead of leaving this blank.
+	disableSarifUpload := os.Getenv("DISABLE_SARIF_UPLOAD") == "true"
+	fullScan := os.Getenv("FULL_SCAN") == "true"
+
+	// SCANNERS is a comma-separated list of enabled scanner backends, e.g.
+	// "semgrep,gosec,gitleaks,codeql,bandit". Defaults to Semgrep alone for
+	// backwards compatibility with existing deployments. Each name's
+	// protocol comes from scannerKind below; anything absent from it is
+	// assumed to speak the Semgrep-service protocol over scannerURLs[name].
+	enabledScanners := []string{"semgrep"}
+	if raw := os.Getenv("SCANNERS"); raw != "" {
+		enabledScanners = nil
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			if name != "" {
+				enabledScanners = append(enabledScanners, name)
+			}
+		}
+	}
+
+	scannerURLs := map[string]string{
+		"semgrep":  semgrepServiceURL,
+		"gosec":    envOr("GOSEC_SERVICE_URL", "http://gosec-service:8087"),
+		"trivy":    envOr("TRIVY_SERVICE_URL", "http://trivy-service:8088"),
+		"gitleaks": envOr("GITLEAKS_SERVICE_URL", "http://gitleaks-service:8089"),
+		"bandit":   os.Getenv("BANDIT_SARIF_URL"),
+		"eslint":   os.Getenv("ESLINT_SARIF_URL"),
+	}
+
+	// scannerKind tells BuildScanners which protocol each name in
+	// EnabledScanners speaks: "sarif" for tools that only publish a
+	// prebuilt SARIF document (Bandit, ESLint), "codeql" to pull alerts
+	// from GitHub's code-scanning API instead of a configured URL, and the
+	// default Semgrep-service protocol for everything else.
+	scannerKind := map[string]string{
+		"bandit": "sarif",
+		"eslint": "sarif",
+		"codeql": "codeql",
+	}
+
 	return intm.Config{
-		ListenAddr:         addr,
-		LogLevel:           logLevel,
-		HTTPTimeoutMinutes: httpTimeoutMinutes,
-		PRAgentURL:         prAgentURL,
-		SemgrepServiceURL:  semgrepServiceURL, // CHANGED: New field name
-		SummarizerURL:      summarizerURL,
-		GitHubMCPURL:       githubMCPURL,
+		ListenAddr:          addr,
+		LogLevel:            logLevel,
+		HTTPTimeoutMinutes:  httpTimeoutMinutes,
+		PRAgentURL:          prAgentURL,
+		SemgrepServiceURL:   semgrepServiceURL, // CHANGED: New field name
+		SummarizerURL:       summarizerURL,
+		GitHubMCPURL:        githubMCPURL,
+		GitHubToken:         os.Getenv("GITHUB_TOKEN"),
+		GitHubWebhookSecret: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		DisableSarifUpload:  disableSarifUpload,
+		FullScan:            fullScan,
+		EnabledScanners:     enabledScanners,
+		ScannerURLs:         scannerURLs,
+		ScannerKind:         scannerKind,
+
+		GitLabURL:           os.Getenv("GITLAB_URL"),
+		GitLabToken:         os.Getenv("GITLAB_TOKEN"),
+		GitLabWebhookSecret: os.Getenv("GITLAB_WEBHOOK_SECRET"),
+
+		BitbucketURL:           os.Getenv("BITBUCKET_URL"),
+		BitbucketUsername:      os.Getenv("BITBUCKET_USERNAME"),
+		BitbucketAppPassword:   os.Getenv("BITBUCKET_APP_PASSWORD"),
+		BitbucketWebhookSecret: os.Getenv("BITBUCKET_WEBHOOK_SECRET"),
+
+		GiteaURL:           os.Getenv("GITEA_URL"),
+		GiteaToken:         os.Getenv("GITEA_TOKEN"),
+		GiteaWebhookSecret: os.Getenv("GITEA_WEBHOOK_SECRET"),
+
+		ReviewStoreDriver: envOr("REVIEW_STORE_DRIVER", "sqlite"),
+		ReviewStoreDSN:    envOr("REVIEW_STORE_DSN", "orchestrator_reviews.db"),
+
+		PublicURL: os.Getenv("PUBLIC_URL"),
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
+	return fallback
 }
 
 func (o *Orchestrator) healthHandler(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,203 @@
+package reviewapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NewHandler builds the read-only /api/v1 surface:
+//
+//	GET /api/v1/reviews                              - list, filterable by ?owner=&repo=&status=&limit=
+//	GET /api/v1/reviews/{owner}/{repo}/{pr}           - latest run for that PR
+//	GET /api/v1/reviews/{id}/artifacts/{stage}        - one stage's raw markdown
+//	GET /api/v1/reviews/{id}/sarif                    - the run's merged SARIF document
+//	GET /api/v1/reviews/{id}/events                   - SSE stream of the run's progress
+//	POST /api/v1/reviews/{id}/progress/{stage}        - internal: accepts a partial-artifact
+//	                                                     callback (e.g. from summarizer's
+//	                                                     streaming checkpoint) and republishes
+//	                                                     it as a stage_progress event
+//
+// It's mounted as a sub-mux under /api/v1 by main.go so the path patterns
+// below don't need to repeat that prefix.
+func NewHandler(rec *Recorder) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reviews", rec.listHandler)
+	mux.HandleFunc("/reviews/", rec.routeReviewSubpath)
+	return mux
+}
+
+func (rec *Recorder) listHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := ListFilter{
+		Owner:  q.Get("owner"),
+		Repo:   q.Get("repo"),
+		Status: Status(q.Get("status")),
+	}
+	if raw := q.Get("limit"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil {
+			filter.Limit = n
+		}
+	}
+
+	runs, err := rec.store.ListRuns(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "failed to list runs", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, runs)
+}
+
+// routeReviewSubpath dispatches everything under /reviews/ since the
+// remaining routes all take a variable-length path: a bare run {id}, an
+// {owner}/{repo}/{pr} triple, or a run {id} followed by /events, /sarif,
+// /artifacts/{stage} or (POST) /progress/{stage}.
+func (rec *Recorder) routeReviewSubpath(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/reviews/"), "/"), "/")
+
+	if len(parts) == 3 && parts[1] == "progress" {
+		rec.progressHandler(w, r, parts[0], Stage(parts[2]))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch len(parts) {
+	case 1:
+		rec.getRunHandler(w, r, parts[0])
+	case 2:
+		switch parts[1] {
+		case "events":
+			rec.eventsHandler(w, r, parts[0])
+		case "sarif":
+			rec.sarifHandler(w, r, parts[0])
+		default:
+			http.NotFound(w, r)
+		}
+	case 3:
+		owner, repo, prStr := parts[0], parts[1], parts[2]
+		pr, err := strconv.Atoi(prStr)
+		if err != nil {
+			http.Error(w, "pr must be a number", http.StatusBadRequest)
+			return
+		}
+		rec.latestRunHandler(w, r, owner, repo, pr)
+	case 4:
+		if parts[1] != "artifacts" {
+			http.NotFound(w, r)
+			return
+		}
+		rec.artifactHandler(w, r, parts[0], Stage(parts[3]))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (rec *Recorder) getRunHandler(w http.ResponseWriter, r *http.Request, id string) {
+	run, err := rec.store.GetRun(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to load run", http.StatusInternalServerError)
+		return
+	}
+	if run == nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, run)
+}
+
+func (rec *Recorder) latestRunHandler(w http.ResponseWriter, r *http.Request, owner, repo string, pr int) {
+	run, err := rec.store.LatestRun(r.Context(), owner, repo, pr)
+	if err != nil {
+		http.Error(w, "failed to load run", http.StatusInternalServerError)
+		return
+	}
+	if run == nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, run)
+}
+
+func (rec *Recorder) artifactHandler(w http.ResponseWriter, r *http.Request, id string, stage Stage) {
+	run, err := rec.store.GetRun(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to load run", http.StatusInternalServerError)
+		return
+	}
+	if run == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	markdown, ok := run.Artifacts[stage]
+	if !ok {
+		http.Error(w, "no artifact for that stage", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(markdown))
+}
+
+// sarifHandler serves the run's merged SARIF document as a downloadable
+// artifact, independent of (and available even when the pipeline skipped)
+// the GitHub code-scanning upload.
+func (rec *Recorder) sarifHandler(w http.ResponseWriter, r *http.Request, id string) {
+	run, err := rec.store.GetRun(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to load run", http.StatusInternalServerError)
+		return
+	}
+	if run == nil || run.SARIF == "" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/sarif+json")
+	w.Write([]byte(run.SARIF))
+}
+
+// progressHandler accepts a streaming agent's partial-artifact callback
+// (currently just the summarizer, mid-stream from Anthropic) and
+// republishes it as a stage_progress SSE event. It's a write endpoint,
+// but an internal one with no durable state behind it, which is why
+// NewHandler's route list marks it internal.
+func (rec *Recorder) progressHandler(w http.ResponseWriter, r *http.Request, id string, stage Stage) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Markdown string `json:"partial_markdown"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad JSON", http.StatusBadRequest)
+		return
+	}
+
+	rec.Progress(id, stage, body.Markdown)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rec *Recorder) eventsHandler(w http.ResponseWriter, r *http.Request, id string) {
+	bus, ok := rec.buses.lookup(id)
+	if !ok {
+		http.Error(w, "unknown or expired run id", http.StatusNotFound)
+		return
+	}
+	serveEvents(w, r, bus)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
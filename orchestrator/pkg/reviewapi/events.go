@@ -0,0 +1,163 @@
+package reviewapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// runEvent is one progress notification published over the course of a
+// run: run_started, stage_completed, stage_progress, or run_finished.
+type runEvent struct {
+	Stage string      `json:"stage,omitempty"`
+	Event string      `json:"event"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+const keepaliveEvery = 15 * time.Second
+
+// runBus fans a single run's progress events out to every client currently
+// watching GET /reviews/{id}/events, plus keeps a small backlog so a
+// reconnect doesn't miss events published between requests. Modeled on
+// semgrep's scan eventBus (semgrep/stream.go).
+type runBus struct {
+	mu       sync.Mutex
+	backlog  []runEvent
+	subs     map[chan runEvent]struct{}
+	finished chan struct{}
+	once     sync.Once
+}
+
+func newRunBus() *runBus {
+	return &runBus{subs: make(map[chan runEvent]struct{}), finished: make(chan struct{})}
+}
+
+func (b *runBus) publish(ev runEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.backlog = append(b.backlog, ev)
+	if len(b.backlog) > 64 {
+		b.backlog = b.backlog[len(b.backlog)-64:]
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (b *runBus) subscribe() (chan runEvent, []runEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := make(chan runEvent, 16)
+	b.subs[ch] = struct{}{}
+	backlog := make([]runEvent, len(b.backlog))
+	copy(backlog, b.backlog)
+	return ch, backlog
+}
+
+func (b *runBus) unsubscribe(ch chan runEvent) {
+	b.mu.Lock()
+	delete(b.subs, ch)
+	b.mu.Unlock()
+}
+
+func (b *runBus) markDone() {
+	b.once.Do(func() { close(b.finished) })
+}
+
+// busRegistry holds one runBus per in-flight (or just-finished) run,
+// keyed by run ID. Entries are dropped streamRetention after the run
+// finishes -- long enough for a slow client to catch the final event.
+type busRegistry struct {
+	mu   sync.Mutex
+	buss map[string]*runBus
+}
+
+const streamRetention = 2 * time.Minute
+
+func newBusRegistry() *busRegistry {
+	return &busRegistry{buss: make(map[string]*runBus)}
+}
+
+func (r *busRegistry) register(id string) *runBus {
+	bus := newRunBus()
+	r.mu.Lock()
+	r.buss[id] = bus
+	r.mu.Unlock()
+	return bus
+}
+
+func (r *busRegistry) lookup(id string) (*runBus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bus, ok := r.buss[id]
+	return bus, ok
+}
+
+func (r *busRegistry) retire(id string) {
+	time.AfterFunc(streamRetention, func() {
+		r.mu.Lock()
+		delete(r.buss, id)
+		r.mu.Unlock()
+	})
+}
+
+// serveEvents writes backlog then forwards live events until the run
+// finishes or the client disconnects.
+func serveEvents(w http.ResponseWriter, r *http.Request, bus *runBus) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ch, backlog := bus.subscribe()
+	defer bus.unsubscribe(ch)
+
+	write := func(ev runEvent) {
+		payload, err := json.Marshal(ev)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Event, payload)
+		flusher.Flush()
+	}
+
+	for _, ev := range backlog {
+		write(ev)
+	}
+
+	ticker := time.NewTicker(keepaliveEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev := <-ch:
+			write(ev)
+		case <-ticker.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-bus.finished:
+			for {
+				select {
+				case ev := <-ch:
+					write(ev)
+				default:
+					return
+				}
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
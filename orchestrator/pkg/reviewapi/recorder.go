@@ -0,0 +1,168 @@
+package reviewapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Recorder is what pr.go's processPR talks to: it owns the Store plus the
+// in-memory runBus registry behind /reviews/{id}/events, so a caller only
+// ever needs a Run ID, not the store and bus registry it came from.
+type Recorder struct {
+	store Store
+	buses *busRegistry
+	log   zerolog.Logger
+}
+
+func NewRecorder(store Store, log zerolog.Logger) *Recorder {
+	return &Recorder{store: store, buses: newBusRegistry(), log: log}
+}
+
+// Start persists a new running Run and returns its ID. Failures here are
+// logged, not returned: a broken review-history store should never stop
+// a PR from being processed.
+func (rec *Recorder) Start(ctx context.Context, platform, owner, repo string, pr int, headSHA string) string {
+	id, err := newRunID()
+	if err != nil {
+		rec.log.Warn().Err(err).Msg("reviewapi: failed to allocate run id, history for this PR won't be recorded")
+		return ""
+	}
+
+	run := &Run{
+		ID:        id,
+		Platform:  platform,
+		Owner:     owner,
+		Repo:      repo,
+		PRNumber:  pr,
+		HeadSHA:   headSHA,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := rec.store.CreateRun(ctx, run); err != nil {
+		rec.log.Warn().Err(err).Msg("reviewapi: failed to persist run start")
+		return ""
+	}
+
+	bus := rec.buses.register(id)
+	bus.publish(runEvent{Event: "run_started", Data: run})
+	return id
+}
+
+// Stage records a completed pipeline stage's artifact markdown and
+// duration, and publishes a stage_completed event to any live watcher.
+// A no-op (including the zero id from a failed Start) is silently
+// ignored, same rationale as Start.
+func (rec *Recorder) Stage(ctx context.Context, id string, stage Stage, markdown string, d time.Duration) {
+	if id == "" {
+		return
+	}
+	run, err := rec.store.GetRun(ctx, id)
+	if err != nil || run == nil {
+		rec.log.Warn().Err(err).Str("run_id", id).Msg("reviewapi: failed to load run for stage update")
+		return
+	}
+	if run.Artifacts == nil {
+		run.Artifacts = make(map[Stage]string)
+	}
+	if run.Timings == nil {
+		run.Timings = make(map[Stage]time.Duration)
+	}
+	run.Artifacts[stage] = markdown
+	run.Timings[stage] = d
+
+	if err := rec.store.UpdateRun(ctx, run); err != nil {
+		rec.log.Warn().Err(err).Str("run_id", id).Msg("reviewapi: failed to persist stage update")
+		return
+	}
+	if bus, ok := rec.buses.lookup(id); ok {
+		bus.publish(runEvent{Event: "stage_completed", Stage: string(stage), Data: map[string]interface{}{"duration_ms": d.Milliseconds()}})
+	}
+}
+
+// Progress publishes an in-progress partial artifact (e.g. the
+// summarizer's streamed markdown so far) to any live watcher of
+// /reviews/{id}/events, without touching the Store. Unlike Stage, this
+// can fire many times per run, so persisting every call would thrash the
+// backing database for no benefit -- the final call into Stage once the
+// stage completes is what gets durably recorded.
+func (rec *Recorder) Progress(id string, stage Stage, partialMarkdown string) {
+	if id == "" {
+		return
+	}
+	if bus, ok := rec.buses.lookup(id); ok {
+		bus.publish(runEvent{Event: "stage_progress", Stage: string(stage), Data: map[string]interface{}{"markdown": partialMarkdown}})
+	}
+}
+
+// Usage records the summarizer's token usage for the run.
+func (rec *Recorder) Usage(ctx context.Context, id string, usage TokenUsage) {
+	if id == "" {
+		return
+	}
+	run, err := rec.store.GetRun(ctx, id)
+	if err != nil || run == nil {
+		return
+	}
+	run.Usage = &usage
+	if err := rec.store.UpdateRun(ctx, run); err != nil {
+		rec.log.Warn().Err(err).Str("run_id", id).Msg("reviewapi: failed to persist token usage")
+	}
+}
+
+// SARIF records the run's merged SARIF document, so it can be downloaded
+// independently of (and even if disabled for) the GitHub code-scanning
+// upload. A no-op if sarif is empty -- most runs have nothing to merge.
+func (rec *Recorder) SARIF(ctx context.Context, id string, sarif string) {
+	if id == "" || sarif == "" {
+		return
+	}
+	run, err := rec.store.GetRun(ctx, id)
+	if err != nil || run == nil {
+		return
+	}
+	run.SARIF = sarif
+	if err := rec.store.UpdateRun(ctx, run); err != nil {
+		rec.log.Warn().Err(err).Str("run_id", id).Msg("reviewapi: failed to persist sarif")
+	}
+}
+
+// Finish marks the run terminal (success, or failed with procErr's
+// message) and closes out its event stream.
+func (rec *Recorder) Finish(ctx context.Context, id string, procErr error) {
+	if id == "" {
+		return
+	}
+	run, err := rec.store.GetRun(ctx, id)
+	if err != nil || run == nil {
+		return
+	}
+	run.EndedAt = time.Now()
+	if procErr != nil {
+		run.Status = StatusFailed
+		run.Error = procErr.Error()
+	} else {
+		run.Status = StatusSuccess
+	}
+	if err := rec.store.UpdateRun(ctx, run); err != nil {
+		rec.log.Warn().Err(err).Str("run_id", id).Msg("reviewapi: failed to persist run completion")
+	}
+
+	if bus, ok := rec.buses.lookup(id); ok {
+		bus.publish(runEvent{Event: "run_finished", Data: run})
+		bus.markDone()
+	}
+	rec.buses.retire(id)
+}
+
+func newRunID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("reviewapi: generate run id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,194 @@
+// Package reviewapi persists each processPR invocation as a Run and
+// exposes it read-only under /api/v1, so operators can see why a posted
+// comment looks the way it does without grepping logs. See api.go for the
+// HTTP surface and recorder.go for how pr.go feeds it.
+package reviewapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	// Pure-Go SQLite driver, registered under the name "sqlite" -- no cgo,
+	// so it doesn't complicate cross-compiling the orchestrator image.
+	_ "modernc.org/sqlite"
+	// Postgres driver, registered under the name "postgres", for
+	// deployments that would rather point this at an existing instance
+	// than run a second stateful service.
+	_ "github.com/lib/pq"
+)
+
+// Status is the lifecycle state of a Run.
+type Status string
+
+const (
+	StatusRunning Status = "running"
+	StatusSuccess Status = "success"
+	StatusFailed  Status = "failed"
+)
+
+// Stage names a pipeline step processPR goes through. Used both as the
+// artifact key ({describe, review, semgrep, summary}) and as the key in
+// Run.Timings.
+type Stage string
+
+const (
+	StageDescribe Stage = "describe"
+	StageReview   Stage = "review"
+	StageSemgrep  Stage = "semgrep"
+	StageSummary  Stage = "summary"
+)
+
+// TokenUsage mirrors the summarizer's anthropicResponse.Usage so a run
+// record carries cost information alongside the markdown it produced.
+type TokenUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Run is one processPR invocation: its identity, terminal status, the raw
+// markdown produced at each stage, how long each stage took, and the
+// summarizer's token usage.
+type Run struct {
+	ID       string `json:"id"`
+	Platform string `json:"platform"`
+	Owner    string `json:"owner"`
+	Repo     string `json:"repo"`
+	PRNumber int    `json:"pr_number"`
+	HeadSHA  string `json:"head_sha"`
+
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at,omitempty"`
+
+	Artifacts map[Stage]string        `json:"artifacts,omitempty"`
+	Timings   map[Stage]time.Duration `json:"timings_ms,omitempty"`
+	Usage     *TokenUsage             `json:"token_usage,omitempty"`
+
+	// SARIF is the merged SARIF 2.1.0 document across every analyzer that
+	// produced one (see scanner.go's mergeSarifDocs), kept alongside the
+	// markdown artifacts so it can be downloaded independently of the
+	// code-scanning upload. Empty if no enabled scanner emits SARIF.
+	SARIF string `json:"sarif,omitempty"`
+}
+
+// MarshalJSON encodes Timings as whole milliseconds, matching its
+// "timings_ms" key, instead of time.Duration's nanoseconds.
+func (r Run) MarshalJSON() ([]byte, error) {
+	type plain Run
+	ms := make(map[Stage]int64, len(r.Timings))
+	for k, v := range r.Timings {
+		ms[k] = v.Milliseconds()
+	}
+	return json.Marshal(struct {
+		plain
+		Timings map[Stage]int64 `json:"timings_ms,omitempty"`
+	}{plain(r), ms})
+}
+
+// Store is the persistence interface a Recorder writes through and the API
+// handlers read from. SQLite is the default; Postgres is a drop-in
+// alternative for deployments that already run one and don't want a
+// second stateful dependency. Both are served by sqlStore (store_ops.go).
+type Store interface {
+	CreateRun(ctx context.Context, run *Run) error
+	UpdateRun(ctx context.Context, run *Run) error
+	GetRun(ctx context.Context, id string) (*Run, error)
+	// LatestRun returns the most recently started run for owner/repo/pr,
+	// or (nil, nil) if none exists yet.
+	LatestRun(ctx context.Context, owner, repo string, pr int) (*Run, error)
+	ListRuns(ctx context.Context, f ListFilter) ([]*Run, error)
+	Close() error
+}
+
+// ListFilter narrows GET /reviews. Zero values are "don't filter on this".
+type ListFilter struct {
+	Owner  string
+	Repo   string
+	Status Status
+	Limit  int
+}
+
+// NewStore opens a Store for driver ("sqlite" or "postgres") and dsn,
+// creating the runs table if it doesn't already exist. An unrecognized
+// driver is a configuration error, not something to silently fall back
+// from.
+func NewStore(driver, dsn string) (Store, error) {
+	switch driver {
+	case "", "sqlite", "sqlite3":
+		return newSQLStore("sqlite", dsn, sqliteSchema)
+	case "postgres", "postgresql":
+		return newSQLStore("postgres", dsn, postgresSchema)
+	default:
+		return nil, fmt.Errorf("reviewapi: unknown store driver %q (want \"sqlite\" or \"postgres\")", driver)
+	}
+}
+
+// sqlStore is the shared database/sql implementation behind both drivers;
+// the only differences are the driver name, the schema DDL, and
+// placeholder syntax, handled by ph() in store_ops.go.
+type sqlStore struct {
+	db     *sql.DB
+	driver string
+}
+
+func newSQLStore(driver, dsn string, schema string) (*sqlStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("reviewapi: open %s store: %w", driver, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("reviewapi: ping %s store: %w", driver, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("reviewapi: create schema on %s store: %w", driver, err)
+	}
+	return &sqlStore{db: db, driver: driver}, nil
+}
+
+func (s *sqlStore) Close() error { return s.db.Close() }
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id TEXT PRIMARY KEY,
+	platform TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	repo TEXT NOT NULL,
+	pr_number INTEGER NOT NULL,
+	head_sha TEXT,
+	status TEXT NOT NULL,
+	error TEXT,
+	started_at TIMESTAMP NOT NULL,
+	ended_at TIMESTAMP,
+	artifacts TEXT,
+	timings TEXT,
+	usage TEXT,
+	sarif TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_runs_owner_repo_pr ON runs(owner, repo, pr_number);
+`
+
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id TEXT PRIMARY KEY,
+	platform TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	repo TEXT NOT NULL,
+	pr_number INTEGER NOT NULL,
+	head_sha TEXT,
+	status TEXT NOT NULL,
+	error TEXT,
+	started_at TIMESTAMPTZ NOT NULL,
+	ended_at TIMESTAMPTZ,
+	artifacts JSONB,
+	timings JSONB,
+	usage JSONB,
+	sarif TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_runs_owner_repo_pr ON runs(owner, repo, pr_number);
+`
@@ -0,0 +1,199 @@
+package reviewapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ph renders the nth (1-based) placeholder for s.driver: sqlite accepts
+// "?" everywhere, postgres needs "$n".
+func (s *sqlStore) ph(n int) string {
+	if s.driver == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func (s *sqlStore) CreateRun(ctx context.Context, run *Run) error {
+	artifacts, timings, usage, err := marshalRun(run)
+	if err != nil {
+		return err
+	}
+
+	q := fmt.Sprintf(`INSERT INTO runs
+		(id, platform, owner, repo, pr_number, head_sha, status, error, started_at, ended_at, artifacts, timings, usage, sarif)
+		VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s)`,
+		s.ph(1), s.ph(2), s.ph(3), s.ph(4), s.ph(5), s.ph(6), s.ph(7), s.ph(8), s.ph(9), s.ph(10), s.ph(11), s.ph(12), s.ph(13), s.ph(14))
+
+	_, err = s.db.ExecContext(ctx, q,
+		run.ID, run.Platform, run.Owner, run.Repo, run.PRNumber, run.HeadSHA,
+		string(run.Status), run.Error, run.StartedAt, nullTime(run.EndedAt), artifacts, timings, usage, run.SARIF)
+	return err
+}
+
+func (s *sqlStore) UpdateRun(ctx context.Context, run *Run) error {
+	artifacts, timings, usage, err := marshalRun(run)
+	if err != nil {
+		return err
+	}
+
+	q := fmt.Sprintf(`UPDATE runs SET status=%s, error=%s, ended_at=%s, artifacts=%s, timings=%s, usage=%s, sarif=%s WHERE id=%s`,
+		s.ph(1), s.ph(2), s.ph(3), s.ph(4), s.ph(5), s.ph(6), s.ph(7), s.ph(8))
+
+	_, err = s.db.ExecContext(ctx, q,
+		string(run.Status), run.Error, nullTime(run.EndedAt), artifacts, timings, usage, run.SARIF, run.ID)
+	return err
+}
+
+func (s *sqlStore) GetRun(ctx context.Context, id string) (*Run, error) {
+	q := fmt.Sprintf(`SELECT id, platform, owner, repo, pr_number, head_sha, status, error, started_at, ended_at, artifacts, timings, usage, sarif
+		FROM runs WHERE id=%s`, s.ph(1))
+	run, err := scanRun(s.db.QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return run, err
+}
+
+func (s *sqlStore) LatestRun(ctx context.Context, owner, repo string, pr int) (*Run, error) {
+	q := fmt.Sprintf(`SELECT id, platform, owner, repo, pr_number, head_sha, status, error, started_at, ended_at, artifacts, timings, usage, sarif
+		FROM runs WHERE owner=%s AND repo=%s AND pr_number=%s ORDER BY started_at DESC LIMIT 1`, s.ph(1), s.ph(2), s.ph(3))
+	run, err := scanRun(s.db.QueryRowContext(ctx, q, owner, repo, pr))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return run, err
+}
+
+func (s *sqlStore) ListRuns(ctx context.Context, f ListFilter) ([]*Run, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	q := `SELECT id, platform, owner, repo, pr_number, head_sha, status, error, started_at, ended_at, artifacts, timings, usage, sarif FROM runs WHERE 1=1`
+	var args []interface{}
+	n := 0
+	addClause := func(clause string, arg interface{}) {
+		n++
+		q += fmt.Sprintf(" AND %s%s", clause, s.ph(n))
+		args = append(args, arg)
+	}
+	if f.Owner != "" {
+		addClause("owner=", f.Owner)
+	}
+	if f.Repo != "" {
+		addClause("repo=", f.Repo)
+	}
+	if f.Status != "" {
+		addClause("status=", string(f.Status))
+	}
+	n++
+	q += fmt.Sprintf(" ORDER BY started_at DESC LIMIT %s", s.ph(n))
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRun(row rowScanner) (*Run, error) {
+	var (
+		run                       Run
+		status                    string
+		headSHA, errText, sarif   sql.NullString
+		endedAt                   sql.NullTime
+		artifacts, timings, usage sql.NullString
+	)
+
+	if err := row.Scan(&run.ID, &run.Platform, &run.Owner, &run.Repo, &run.PRNumber, &headSHA,
+		&status, &errText, &run.StartedAt, &endedAt, &artifacts, &timings, &usage, &sarif); err != nil {
+		return nil, err
+	}
+
+	run.Status = Status(status)
+	run.HeadSHA = headSHA.String
+	run.Error = errText.String
+	run.SARIF = sarif.String
+	if endedAt.Valid {
+		run.EndedAt = endedAt.Time
+	}
+
+	if artifacts.Valid && artifacts.String != "" {
+		if err := json.Unmarshal([]byte(artifacts.String), &run.Artifacts); err != nil {
+			return nil, fmt.Errorf("reviewapi: decode artifacts for run %s: %w", run.ID, err)
+		}
+	}
+	if timings.Valid && timings.String != "" {
+		var ms map[Stage]int64
+		if err := json.Unmarshal([]byte(timings.String), &ms); err != nil {
+			return nil, fmt.Errorf("reviewapi: decode timings for run %s: %w", run.ID, err)
+		}
+		run.Timings = make(map[Stage]time.Duration, len(ms))
+		for k, v := range ms {
+			run.Timings[k] = time.Duration(v) * time.Millisecond
+		}
+	}
+	if usage.Valid && usage.String != "" {
+		var u TokenUsage
+		if err := json.Unmarshal([]byte(usage.String), &u); err != nil {
+			return nil, fmt.Errorf("reviewapi: decode usage for run %s: %w", run.ID, err)
+		}
+		run.Usage = &u
+	}
+
+	return &run, nil
+}
+
+func marshalRun(run *Run) (artifacts, timings, usage []byte, err error) {
+	if len(run.Artifacts) > 0 {
+		if artifacts, err = json.Marshal(run.Artifacts); err != nil {
+			return nil, nil, nil, fmt.Errorf("reviewapi: encode artifacts: %w", err)
+		}
+	}
+	if len(run.Timings) > 0 {
+		ms := make(map[Stage]int64, len(run.Timings))
+		for k, v := range run.Timings {
+			ms[k] = v.Milliseconds()
+		}
+		if timings, err = json.Marshal(ms); err != nil {
+			return nil, nil, nil, fmt.Errorf("reviewapi: encode timings: %w", err)
+		}
+	}
+	if run.Usage != nil {
+		if usage, err = json.Marshal(run.Usage); err != nil {
+			return nil, nil, nil, fmt.Errorf("reviewapi: encode usage: %w", err)
+		}
+	}
+	return artifacts, timings, usage, nil
+}
+
+// nullTime turns a zero time.Time into a driver-understood NULL instead of
+// the "0001-01-01" sentinel most SQL drivers would otherwise store.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
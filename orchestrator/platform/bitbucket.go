@@ -0,0 +1,181 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
+
+	"github.com/rs/zerolog"
+)
+
+// bitbucketPREvent mirrors the subset of Bitbucket's pullrequest:created /
+// pullrequest:updated webhook payload the pipeline needs.
+type bitbucketPREvent struct {
+	PullRequest struct {
+		ID          int    `json:"id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Source      struct {
+			Branch struct {
+				Name string `json:"name"`
+			} `json:"branch"`
+			Commit struct {
+				Hash string `json:"hash"`
+			} `json:"commit"`
+		} `json:"source"`
+		Destination struct {
+			Branch struct {
+				Name string `json:"name"`
+			} `json:"branch"`
+		} `json:"destination"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	} `json:"pullrequest"`
+	Repository struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"` // "owner/repo"
+	} `json:"repository"`
+}
+
+// Bitbucket talks to the Bitbucket Cloud REST API directly. eventKey comes
+// from the X-Event-Key header Bitbucket sends on every webhook request,
+// since (unlike GitHub/Gitea) the event type isn't in the JSON body.
+type Bitbucket struct {
+	client        *httpx.Client
+	log           zerolog.Logger
+	baseURL       string // e.g. https://api.bitbucket.org/2.0
+	username      string // Basic auth
+	appPassword   string
+	webhookSecret string // BITBUCKET_WEBHOOK_SECRET; empty disables verification
+}
+
+func NewBitbucket(client *httpx.Client, log zerolog.Logger, baseURL, username, appPassword, webhookSecret string) *Bitbucket {
+	return &Bitbucket{
+		client:        client,
+		log:           log,
+		baseURL:       baseURL,
+		username:      username,
+		appPassword:   appPassword,
+		webhookSecret: webhookSecret,
+	}
+}
+
+func (p *Bitbucket) Name() string { return "bitbucket" }
+
+// VerifySignature checks X-Hub-Signature, the HMAC-SHA256 Bitbucket Server
+// webhooks sign their body with. Bitbucket Cloud doesn't sign webhooks at
+// all, so an unset secret (the Cloud default) leaves verification off.
+func (p *Bitbucket) VerifySignature(r *http.Request) error {
+	if p.webhookSecret == "" {
+		return nil
+	}
+
+	sig := r.Header.Get("X-Hub-Signature")
+	if sig == "" {
+		return fmt.Errorf("missing X-Hub-Signature header")
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (p *Bitbucket) ParseWebhook(r *http.Request) (intm.PRMetadata, EventKind, error) {
+	eventKey := r.Header.Get("X-Event-Key")
+
+	var event bitbucketPREvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		return intm.PRMetadata{}, EventIgnored, fmt.Errorf("decode bitbucket webhook: %w", err)
+	}
+
+	owner, repo := splitFullName(event.Repository.FullName, event.Repository.Name)
+	meta := intm.PRMetadata{
+		RepoOwner:    owner,
+		RepoName:     repo,
+		PRNumber:     event.PullRequest.ID,
+		HeadSHA:      event.PullRequest.Source.Commit.Hash,
+		Title:        event.PullRequest.Title,
+		Body:         event.PullRequest.Description,
+		SourceBranch: event.PullRequest.Source.Branch.Name,
+		TargetBranch: event.PullRequest.Destination.Branch.Name,
+		URL:          event.PullRequest.Links.HTML.Href,
+	}
+
+	kind := EventIgnored
+	switch eventKey {
+	case "pullrequest:created", "pullrequest:updated":
+		kind = EventReviewable
+	}
+	return meta, kind, nil
+}
+
+func (p *Bitbucket) PostComment(ctx context.Context, meta intm.PRMetadata, body string) error {
+	url := fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d/comments", p.baseURL, meta.RepoOwner, meta.RepoName, meta.PRNumber)
+
+	payload := map[string]interface{}{
+		"content": map[string]string{"raw": body},
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode bitbucket comment payload: %w", err)
+	}
+
+	p.log.Debug().Str("url", url).Int("pr", meta.PRNumber).Msg("calling Bitbucket API to post comment")
+
+	resp, err := p.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("create bitbucket comment request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth(p.username, p.appPassword)
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("bitbucket api http error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("bitbucket api returned status %d", resp.StatusCode)
+	}
+
+	p.log.Debug().Msg("Bitbucket comment posted successfully")
+	return nil
+}
+
+// splitFullName splits Bitbucket's "owner/repo" full_name. It falls back
+// to name if fullName is empty.
+func splitFullName(fullName, name string) (owner, repo string) {
+	if fullName == "" {
+		return "", name
+	}
+	idx := strings.Index(fullName, "/")
+	if idx < 0 {
+		return "", fullName
+	}
+	return fullName[:idx], fullName[idx+1:]
+}
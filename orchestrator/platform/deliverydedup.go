@@ -0,0 +1,79 @@
+package platform
+
+import (
+	"container/list"
+	"sync"
+)
+
+// deliveryDedup is a size-bounded LRU of recently seen delivery IDs, used
+// to recognize a forge's automatic webhook retries (GitHub redelivers a
+// webhook that didn't get a timely 2xx) so the pipeline isn't re-run for
+// an event it already processed. Bounded rather than TTL'd: delivery IDs
+// are opaque and retries land within seconds to minutes, so capacity
+// alone is enough to bound memory without tracking time.
+type deliveryDedup struct {
+	mu         sync.Mutex
+	maxEntries int
+	seen       map[string]*list.Element
+	order      *list.List // front = most recently seen
+}
+
+func newDeliveryDedup(maxEntries int) *deliveryDedup {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &deliveryDedup{
+		maxEntries: maxEntries,
+		seen:       make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// seenBefore reports whether id was already recorded via mark, without
+// recording it itself. Split from marking so a caller can reject an
+// already-accepted redelivery without also marking a *new* delivery seen
+// before it's known whether that delivery will be processed successfully.
+func (d *deliveryDedup) seenBefore(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if el, ok := d.seen[id]; ok {
+		d.order.MoveToFront(el)
+		return true
+	}
+	return false
+}
+
+// mark records id as seen, so a later seenBefore(id) reports true. Call
+// only once the delivery has actually been accepted (processed
+// successfully, or classified as an event the pipeline ignores) --
+// marking one that ended in a 5xx would make GitHub's retry of that same
+// delivery look like a no-op duplicate instead of running the pipeline.
+func (d *deliveryDedup) mark(id string) {
+	if id == "" {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if el, ok := d.seen[id]; ok {
+		d.order.MoveToFront(el)
+		return
+	}
+
+	el := d.order.PushFront(id)
+	d.seen[id] = el
+	for d.order.Len() > d.maxEntries {
+		oldest := d.order.Back()
+		if oldest == nil {
+			break
+		}
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(string))
+	}
+}
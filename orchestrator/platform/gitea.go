@@ -0,0 +1,142 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
+
+	"github.com/rs/zerolog"
+)
+
+// giteaPREvent mirrors the subset of Gitea's pull_request webhook payload
+// the pipeline needs. Gitea's API deliberately mirrors GitHub's shape.
+type giteaPREvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+		Head    struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+// Gitea talks to a Gitea instance's REST API directly.
+type Gitea struct {
+	client        *httpx.Client
+	log           zerolog.Logger
+	baseURL       string // e.g. https://gitea.example.com
+	token         string // Authorization: token <token>
+	webhookSecret string // GITEA_WEBHOOK_SECRET; empty disables verification
+}
+
+func NewGitea(client *httpx.Client, log zerolog.Logger, baseURL, token, webhookSecret string) *Gitea {
+	return &Gitea{client: client, log: log, baseURL: baseURL, token: token, webhookSecret: webhookSecret}
+}
+
+func (p *Gitea) Name() string { return "gitea" }
+
+// VerifySignature checks X-Gitea-Signature, the hex HMAC-SHA256 of the raw
+// request body (no "sha256=" prefix, unlike GitHub's header).
+func (p *Gitea) VerifySignature(r *http.Request) error {
+	if p.webhookSecret == "" {
+		return nil
+	}
+
+	sig := r.Header.Get("X-Gitea-Signature")
+	if sig == "" {
+		return fmt.Errorf("missing X-Gitea-Signature header")
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (p *Gitea) ParseWebhook(r *http.Request) (intm.PRMetadata, EventKind, error) {
+	var event giteaPREvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		return intm.PRMetadata{}, EventIgnored, fmt.Errorf("decode gitea webhook: %w", err)
+	}
+
+	meta := intm.PRMetadata{
+		RepoOwner:    event.Repository.Owner.Login,
+		RepoName:     event.Repository.Name,
+		PRNumber:     event.Number,
+		HeadSHA:      event.PullRequest.Head.SHA,
+		Title:        event.PullRequest.Title,
+		Body:         event.PullRequest.Body,
+		SourceBranch: event.PullRequest.Head.Ref,
+		TargetBranch: event.PullRequest.Base.Ref,
+		URL:          event.PullRequest.HTMLURL,
+	}
+
+	kind := EventIgnored
+	if event.Action == "opened" || event.Action == "reopened" || event.Action == "synchronized" {
+		kind = EventReviewable
+	}
+	return meta, kind, nil
+}
+
+func (p *Gitea) PostComment(ctx context.Context, meta intm.PRMetadata, body string) error {
+	url := fmt.Sprintf("%s/api/v1/repos/%s/%s/issues/%d/comments", p.baseURL, meta.RepoOwner, meta.RepoName, meta.PRNumber)
+
+	encoded, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return fmt.Errorf("encode gitea comment payload: %w", err)
+	}
+
+	p.log.Debug().Str("url", url).Int("pr", meta.PRNumber).Msg("calling Gitea API to post comment")
+
+	resp, err := p.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("create gitea comment request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "token "+p.token)
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("gitea api http error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("gitea api returned status %d", resp.StatusCode)
+	}
+
+	p.log.Debug().Msg("Gitea comment posted successfully")
+	return nil
+}
@@ -0,0 +1,237 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
+
+	"github.com/rs/zerolog"
+)
+
+// githubPREvent mirrors the subset of GitHub's pull_request webhook
+// payload the pipeline needs.
+type githubPREvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+		Head    struct {
+			Ref  string `json:"ref"`
+			SHA  string `json:"sha"`
+			Repo struct {
+				Name  string `json:"name"`
+				Owner struct {
+					Login string `json:"login"`
+				} `json:"owner"`
+			} `json:"repo"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+// GitHub is the reference Platform, posting through github-mcp-server
+// rather than GitHub's API directly (the orchestrator never holds a
+// general-purpose GitHub token beyond the optional one used for SARIF
+// uploads).
+type GitHub struct {
+	client        *httpx.Client
+	log           zerolog.Logger
+	mcpURL        string
+	webhookSecret string // GITHUB_WEBHOOK_SECRET; empty disables verification
+
+	// deliveries dedupes X-GitHub-Delivery IDs so GitHub's automatic
+	// webhook retries (on a slow or dropped response) don't re-run the
+	// pipeline for an event already accepted.
+	deliveries *deliveryDedup
+}
+
+func NewGitHub(client *httpx.Client, log zerolog.Logger, mcpURL, webhookSecret string) *GitHub {
+	return &GitHub{
+		client:        client,
+		log:           log,
+		mcpURL:        mcpURL,
+		webhookSecret: webhookSecret,
+		deliveries:    newDeliveryDedup(1000),
+	}
+}
+
+func (p *GitHub) Name() string { return "github" }
+
+// VerifySignature checks X-Hub-Signature-256, GitHub's HMAC-SHA256 of the
+// raw request body, then rejects X-GitHub-Delivery IDs already marked via
+// MarkDelivered. It deliberately does not reject on X-GitHub-Event: GitHub
+// sends non-pull_request events too (notably "ping" on webhook creation),
+// and ParseWebhook already classifies those as EventIgnored for
+// webhookHandler to return a benign 200 for -- rejecting them here instead
+// would mis-report routing as an auth failure. An unset webhookSecret
+// leaves HMAC verification off, matching this handler's pre-existing
+// unauthenticated behavior, but the delivery check still runs since it
+// doesn't depend on the secret.
+//
+// The body is read in full through a TeeReader into a buffer, and r.Body
+// is replaced with that buffer so ParseWebhook's JSON decode still sees
+// the whole body.
+func (p *GitHub) VerifySignature(r *http.Request) error {
+	var buf bytes.Buffer
+	body, err := io.ReadAll(io.TeeReader(r.Body, &buf))
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	r.Body = io.NopCloser(&buf)
+
+	if p.webhookSecret != "" {
+		sig := r.Header.Get("X-Hub-Signature-256")
+		if sig == "" {
+			return fmt.Errorf("missing X-Hub-Signature-256 header")
+		}
+
+		mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+		mac.Write(body)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(expected), []byte(sig)) {
+			return fmt.Errorf("signature mismatch")
+		}
+	}
+
+	if p.deliveries.seenBefore(r.Header.Get("X-GitHub-Delivery")) {
+		return ErrDuplicateDelivery
+	}
+
+	return nil
+}
+
+// MarkDelivered records r's X-GitHub-Delivery as accepted, so a later
+// redelivery of it is recognized as a genuine no-op duplicate by
+// VerifySignature instead of re-running the pipeline. Callers must only
+// call this once the delivery's outcome is a 2xx (processed successfully,
+// or ignored as a non-reviewable event) -- a delivery that ended in an
+// error must stay unmarked so GitHub's retry of it is processed normally.
+func (p *GitHub) MarkDelivered(r *http.Request) {
+	p.deliveries.mark(r.Header.Get("X-GitHub-Delivery"))
+}
+
+func (p *GitHub) ParseWebhook(r *http.Request) (intm.PRMetadata, EventKind, error) {
+	var event githubPREvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		return intm.PRMetadata{}, EventIgnored, fmt.Errorf("decode github webhook: %w", err)
+	}
+
+	meta := intm.PRMetadata{
+		RepoOwner:    event.Repository.Owner.Login,
+		RepoName:     event.Repository.Name,
+		PRNumber:     event.Number,
+		HeadSHA:      event.PullRequest.Head.SHA,
+		Title:        event.PullRequest.Title,
+		Body:         event.PullRequest.Body,
+		SourceBranch: event.PullRequest.Head.Ref,
+		TargetBranch: event.PullRequest.Base.Ref,
+		URL:          event.PullRequest.HTMLURL,
+	}
+
+	kind := EventIgnored
+	if event.Action == "opened" || event.Action == "reopened" || event.Action == "synchronize" {
+		kind = EventReviewable
+	}
+	return meta, kind, nil
+}
+
+func (p *GitHub) PostComment(ctx context.Context, meta intm.PRMetadata, body string) error {
+	payload := intm.GitHubCommentRequest{
+		Action:     "comment_pr",
+		PR:         meta,
+		Body:       body,
+		BodyFormat: "markdown",
+	}
+
+	p.log.Debug().Str("url", p.mcpURL).Int("pr", meta.PRNumber).Msg("calling GitHub MCP to post comment")
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode github comment payload: %w", err)
+	}
+
+	resp, err := p.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.mcpURL, bytes.NewReader(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("create github comment request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("github mcp http error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("github mcp returned status %d", resp.StatusCode)
+	}
+
+	p.log.Debug().Msg("GitHub MCP comment posted successfully")
+	return nil
+}
+
+// PostReview implements InlineReviewer, posting inline per-line comments
+// via GitHub's Pull Request Reviews API instead of a single issue
+// comment. Comments are sent as given; if GitHub rejects the review (for
+// example, a comment on a line outside the diff), github-mcp-server posts
+// fallbackBody as a plain PR comment instead.
+func (p *GitHub) PostReview(ctx context.Context, meta intm.PRMetadata, comments []intm.ReviewComment, fallbackBody string) error {
+	payload := intm.GitHubReviewRequest{
+		Action:       "review_pr",
+		PR:           meta,
+		Event:        "COMMENT",
+		Comments:     comments,
+		FallbackBody: fallbackBody,
+	}
+
+	p.log.Debug().
+		Str("url", p.mcpURL).
+		Int("pr", meta.PRNumber).
+		Int("comments", len(comments)).
+		Msg("calling GitHub MCP to post inline review")
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode github review payload: %w", err)
+	}
+
+	resp, err := p.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.mcpURL, bytes.NewReader(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("create github review request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("github mcp http error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("github mcp returned status %d", resp.StatusCode)
+	}
+
+	p.log.Debug().Msg("GitHub MCP review posted successfully")
+	return nil
+}
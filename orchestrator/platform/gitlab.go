@@ -0,0 +1,161 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
+
+	"github.com/rs/zerolog"
+)
+
+// gitlabMREvent mirrors the subset of GitLab's Merge Request Hook payload
+// the pipeline needs. See:
+// https://docs.gitlab.com/ee/user/project/integrations/webhook_events.html#merge-request-events
+type gitlabMREvent struct {
+	ObjectKind string `json:"object_kind"`
+	Project    struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		Name              string `json:"name"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		Description  string `json:"description"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		Action       string `json:"action"`
+		URL          string `json:"url"`
+		LastCommit   struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+}
+
+// GitLab talks to a GitLab instance's REST API directly, since there's no
+// gitlab equivalent of github-mcp-server in this deployment.
+type GitLab struct {
+	client        *httpx.Client
+	log           zerolog.Logger
+	baseURL       string // e.g. https://gitlab.example.com
+	token         string // PRIVATE-TOKEN
+	webhookSecret string // GITLAB_WEBHOOK_SECRET; empty disables verification
+}
+
+func NewGitLab(client *httpx.Client, log zerolog.Logger, baseURL, token, webhookSecret string) *GitLab {
+	return &GitLab{client: client, log: log, baseURL: baseURL, token: token, webhookSecret: webhookSecret}
+}
+
+func (p *GitLab) Name() string { return "gitlab" }
+
+// VerifySignature checks X-Gitlab-Token, a plain shared secret GitLab
+// echoes back verbatim rather than an HMAC over the body.
+func (p *GitLab) VerifySignature(r *http.Request) error {
+	if p.webhookSecret == "" {
+		return nil
+	}
+
+	token := r.Header.Get("X-Gitlab-Token")
+	if token == "" {
+		return fmt.Errorf("missing X-Gitlab-Token header")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(p.webhookSecret)) != 1 {
+		return fmt.Errorf("token mismatch")
+	}
+	return nil
+}
+
+func (p *GitLab) ParseWebhook(r *http.Request) (intm.PRMetadata, EventKind, error) {
+	var event gitlabMREvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		return intm.PRMetadata{}, EventIgnored, fmt.Errorf("decode gitlab webhook: %w", err)
+	}
+
+	if event.ObjectKind != "merge_request" {
+		return intm.PRMetadata{}, EventIgnored, nil
+	}
+
+	owner, repo := splitPathWithNamespace(event.Project.PathWithNamespace, event.Project.Name)
+	meta := intm.PRMetadata{
+		RepoOwner:    owner,
+		RepoName:     repo,
+		PRNumber:     event.ObjectAttributes.IID,
+		HeadSHA:      event.ObjectAttributes.LastCommit.ID,
+		Title:        event.ObjectAttributes.Title,
+		Body:         event.ObjectAttributes.Description,
+		SourceBranch: event.ObjectAttributes.SourceBranch,
+		TargetBranch: event.ObjectAttributes.TargetBranch,
+		URL:          event.ObjectAttributes.URL,
+	}
+
+	kind := EventIgnored
+	switch event.ObjectAttributes.Action {
+	case "open", "reopen", "update":
+		kind = EventReviewable
+	}
+	return meta, kind, nil
+}
+
+func (p *GitLab) PostComment(ctx context.Context, meta intm.PRMetadata, body string) error {
+	url := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests/%d/notes",
+		p.baseURL, projectPathEscaped(meta.RepoOwner, meta.RepoName), meta.PRNumber)
+
+	encoded, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return fmt.Errorf("encode gitlab note payload: %w", err)
+	}
+
+	p.log.Debug().Str("url", url).Int("mr", meta.PRNumber).Msg("calling GitLab API to post note")
+
+	resp, err := p.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("create gitlab note request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("PRIVATE-TOKEN", p.token)
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("gitlab api http error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("gitlab api returned status %d", resp.StatusCode)
+	}
+
+	p.log.Debug().Msg("GitLab note posted successfully")
+	return nil
+}
+
+// splitPathWithNamespace splits GitLab's "group/subgroup/project" path
+// into an owner (everything but the last segment) and repo name. It falls
+// back to name if pathWithNamespace is empty.
+func splitPathWithNamespace(pathWithNamespace, name string) (owner, repo string) {
+	if pathWithNamespace == "" {
+		return "", name
+	}
+	idx := strings.LastIndex(pathWithNamespace, "/")
+	if idx < 0 {
+		return "", pathWithNamespace
+	}
+	return pathWithNamespace[:idx], pathWithNamespace[idx+1:]
+}
+
+// projectPathEscaped rebuilds and URL-encodes the "owner/repo" project
+// path GitLab's API expects in place of a numeric project ID.
+func projectPathEscaped(owner, repo string) string {
+	path := repo
+	if owner != "" {
+		path = owner + "/" + repo
+	}
+	return url.PathEscape(path)
+}
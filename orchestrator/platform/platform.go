@@ -0,0 +1,68 @@
+// Package platform abstracts "which forge is this PR on" so the
+// orchestrator's review pipeline (PrepareRepository → PR-Agent →
+// scanners → Summarizer) runs unchanged against GitHub, GitLab,
+// Bitbucket, or Gitea. Each Platform turns that forge's webhook payload
+// into an intm.PRMetadata, verifies the forge's signing scheme, and posts
+// the finished review back through that forge's own API.
+package platform
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	intm "orchestrator/internal"
+)
+
+// ErrDuplicateDelivery is returned by VerifySignature when a platform
+// recognizes the request as a redelivery of an event it already accepted
+// (e.g. GitHub's X-GitHub-Delivery retried after a slow response). The
+// caller should treat it as a no-op 200, not an auth failure.
+var ErrDuplicateDelivery = errors.New("platform: duplicate webhook delivery")
+
+// EventKind classifies a parsed webhook event so the caller can decide
+// whether to run the review pipeline at all.
+type EventKind int
+
+const (
+	// EventIgnored is any webhook event the pipeline shouldn't review
+	// (e.g. a PR close or merge event).
+	EventIgnored EventKind = iota
+	// EventReviewable is a PR open, reopen, or new-commits-pushed event.
+	EventReviewable
+)
+
+// Platform is one forge's webhook + comment-posting integration. The
+// orchestrator registers one per configured provider behind
+// /webhook/{provider}; processPR itself stays forge-agnostic.
+type Platform interface {
+	// Name identifies the platform for logging, e.g. "github".
+	Name() string
+	// VerifySignature checks the webhook request's signing header against
+	// this platform's configured secret. It must run before the body is
+	// consumed, since most schemes sign the raw request body.
+	VerifySignature(r *http.Request) error
+	// ParseWebhook decodes r's body into PRMetadata and classifies the
+	// event. LocalPath is left unset; PrepareRepository fills it in.
+	ParseWebhook(r *http.Request) (intm.PRMetadata, EventKind, error)
+	// PostComment posts a single markdown comment on the PR.
+	PostComment(ctx context.Context, meta intm.PRMetadata, body string) error
+}
+
+// InlineReviewer is implemented by platforms whose API can anchor review
+// comments to specific diff lines (GitHub's Reviews API). Platforms
+// without one just get every finding folded into the PostComment summary
+// instead.
+type InlineReviewer interface {
+	PostReview(ctx context.Context, meta intm.PRMetadata, comments []intm.ReviewComment, fallbackBody string) error
+}
+
+// DeliveryMarker is implemented by platforms that dedupe redelivered
+// webhooks (GitHub retries a delivery that didn't get a timely 2xx).
+// webhookHandler calls MarkDelivered once it's actually accepted the
+// request -- processed it successfully, or classified it as an ignored
+// event -- so VerifySignature's duplicate check only ever drops a
+// redelivery of something already accepted, never a retry of a failure.
+type DeliveryMarker interface {
+	MarkDelivered(r *http.Request)
+}
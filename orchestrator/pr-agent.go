@@ -8,6 +8,7 @@ import (
     "net/http"
 
     intm "orchestrator/internal"
+    "orchestrator/internal/httpx"
 
     "github.com/rs/zerolog"
 )
@@ -15,7 +16,7 @@ import (
 func CallPRAgentDescribe(
     ctx context.Context,
     log zerolog.Logger,
-    client *http.Client,
+    client *httpx.Client,
     baseURL string,
     meta intm.PRMetadata,
 ) (*intm.PRAgentDescribeOut, error) {
@@ -29,27 +30,24 @@ func CallPRAgentDescribe(
         Str("mode", payload.Mode).
         Msg("calling PR-Agent describe")
 
-    var buf bytes.Buffer
-    if err := json.NewEncoder(&buf).Encode(payload); err != nil {
-        return nil, fmt.Errorf("encode pr-agent describe payload: %w", err)
-    }
-
-    req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, &buf)
+    encoded, err := json.Marshal(payload)
     if err != nil {
-        return nil, fmt.Errorf("create pr-agent describe request: %w", err)
+        return nil, fmt.Errorf("encode pr-agent describe payload: %w", err)
     }
-    req.Header.Set("Content-Type", "application/json")
 
-    resp, err := client.Do(req)
+    resp, err := client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+        req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, bytes.NewReader(encoded))
+        if err != nil {
+            return nil, fmt.Errorf("create pr-agent describe request: %w", err)
+        }
+        req.Header.Set("Content-Type", "application/json")
+        return req, nil
+    })
     if err != nil {
         return nil, fmt.Errorf("pr-agent describe http error: %w", err)
     }
     defer resp.Body.Close()
 
-    if resp.StatusCode >= 300 {
-        return nil, fmt.Errorf("pr-agent describe returned status %d", resp.StatusCode)
-    }
-
     var out intm.PRAgentDescribeOut
     if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
         return nil, fmt.Errorf("decode pr-agent describe response: %w", err)
@@ -62,7 +60,7 @@ func CallPRAgentDescribe(
 func CallPRAgentReview(
     ctx context.Context,
     log zerolog.Logger,
-    client *http.Client,
+    client *httpx.Client,
     baseURL string,
     meta intm.PRMetadata,
     descriptionMarkdown string,
@@ -78,27 +76,24 @@ func CallPRAgentReview(
         Str("mode", payload.Mode).
         Msg("calling PR-Agent review")
 
-    var buf bytes.Buffer
-    if err := json.NewEncoder(&buf).Encode(payload); err != nil {
-        return nil, fmt.Errorf("encode pr-agent review payload: %w", err)
-    }
-
-    req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, &buf)
+    encoded, err := json.Marshal(payload)
     if err != nil {
-        return nil, fmt.Errorf("create pr-agent review request: %w", err)
+        return nil, fmt.Errorf("encode pr-agent review payload: %w", err)
     }
-    req.Header.Set("Content-Type", "application/json")
 
-    resp, err := client.Do(req)
+    resp, err := client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+        req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, bytes.NewReader(encoded))
+        if err != nil {
+            return nil, fmt.Errorf("create pr-agent review request: %w", err)
+        }
+        req.Header.Set("Content-Type", "application/json")
+        return req, nil
+    })
     if err != nil {
         return nil, fmt.Errorf("pr-agent review http error: %w", err)
     }
     defer resp.Body.Close()
 
-    if resp.StatusCode >= 300 {
-        return nil, fmt.Errorf("pr-agent review returned status %d", resp.StatusCode)
-    }
-
     var out intm.PRAgentReviewOut
     if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
         return nil, fmt.Errorf("decode pr-agent review response: %w", err)
@@ -2,124 +2,114 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	intm "orchestrator/internal"
+	"orchestrator/internal/actions"
+	"orchestrator/pkg/reviewapi"
+	"orchestrator/platform"
 )
 
-type githubPREvent struct {
-	Action      string `json:"action"`
-	Number      int    `json:"number"`
-	PullRequest struct {
-		Title   string `json:"title"`
-		Body    string `json:"body"`
-		HTMLURL string `json:"html_url"`
-		Head    struct {
-			Ref  string `json:"ref"`
-			SHA  string `json:"sha"`
-			Repo struct {
-				Name  string `json:"name"`
-				Owner struct {
-					Login string `json:"login"`
-				} `json:"owner"`
-			} `json:"repo"`
-		} `json:"head"`
-		Base struct {
-			Ref string `json:"ref"`
-		} `json:"base"`
-	} `json:"pull_request"`
-	Repository struct {
-		Name  string `json:"name"`
-		Owner struct {
-			Login string `json:"login"`
-		} `json:"owner"`
-	} `json:"repository"`
-}
-
-func (o *Orchestrator) prWebhookHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+// webhookHandler returns an http.HandlerFunc bound to a specific Platform,
+// so /webhook/github, /webhook/gitlab, etc. all share the same decode →
+// filter → processPR flow and differ only in how plat parses and verifies
+// its forge's payload.
+func (o *Orchestrator) webhookHandler(plat platform.Platform) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
 
-	var event githubPREvent
-	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-		o.log.Error().Err(err).Msg("failed to decode webhook payload")
-		http.Error(w, "invalid payload", http.StatusBadRequest)
-		return
-	}
+		if err := plat.VerifySignature(r); err != nil {
+			if errors.Is(err, platform.ErrDuplicateDelivery) {
+				o.log.Debug().Str("platform", plat.Name()).Msg("ignoring redelivered webhook")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte("duplicate delivery, ignored"))
+				return
+			}
+			o.log.Warn().Err(err).Str("platform", plat.Name()).Msg("webhook signature verification failed")
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
 
-	o.log.Debug().
-		Int("pr_number", event.Number).
-		Str("action", event.Action).
-		Msg("received GitHub PR webhook")
+		meta, kind, err := plat.ParseWebhook(r)
+		if err != nil {
+			o.log.Error().Err(err).Str("platform", plat.Name()).Msg("failed to decode webhook payload")
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
 
-	if event.Action != "opened" && event.Action != "reopened" && event.Action != "synchronize" {
 		o.log.Debug().
-			Str("action", event.Action).
-			Msg("ignoring PR event action")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ignored"))
-		return
-	}
+			Str("platform", plat.Name()).
+			Int("pr_number", meta.PRNumber).
+			Msg("received PR webhook")
+
+		if kind != platform.EventReviewable {
+			o.log.Debug().Str("platform", plat.Name()).Msg("ignoring webhook event")
+			if marker, ok := plat.(platform.DeliveryMarker); ok {
+				marker.MarkDelivered(r)
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("ignored"))
+			return
+		}
 
-	meta := intm.PRMetadata{
-		RepoOwner:    event.Repository.Owner.Login,
-		RepoName:     event.Repository.Name,
-		PRNumber:     event.Number,
-		HeadSHA:      event.PullRequest.Head.SHA,
-		Title:        event.PullRequest.Title,
-		Body:         event.PullRequest.Body,
-		SourceBranch: event.PullRequest.Head.Ref,
-		TargetBranch: event.PullRequest.Base.Ref,
-		URL:          event.PullRequest.HTMLURL,
-	}
+		// Use context.Background() instead of r.Context()
+		// This prevents cancellation if webhook client disconnects
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+		defer cancel()
+
+		o.log.Info().
+			Str("platform", plat.Name()).
+			Str("repo", meta.RepoOwner+"/"+meta.RepoName).
+			Int("pr", meta.PRNumber).
+			Dur("timeout", 20*time.Minute).
+			Msg("starting PR processing with background context")
+
+		// Optional: Monitor request context separately
+		go func() {
+			<-r.Context().Done()
+			if r.Context().Err() != nil {
+				o.log.Warn().
+					Err(r.Context().Err()).
+					Msg("webhook client disconnected, but processing continues")
+			}
+		}()
+
+		if err := o.processPR(ctx, plat, meta); err != nil {
+			o.log.Error().Err(err).Msg("failed to process PR")
+			http.Error(w, "processing error", http.StatusInternalServerError)
+			return
+		}
 
-	// Use context.Background() instead of r.Context()
-	// This prevents cancellation if webhook client disconnects
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
-	defer cancel()
+		o.log.Info().
+			Str("repo", meta.RepoOwner+"/"+meta.RepoName).
+			Int("pr", meta.PRNumber).
+			Msg("PR processing completed successfully")
 
-	o.log.Info().
-		Str("repo", meta.RepoOwner+"/"+meta.RepoName).
-		Int("pr", meta.PRNumber).
-		Dur("timeout", 20*time.Minute).
-		Msg("starting PR processing with background context")
-
-	// Optional: Monitor request context separately
-	go func() {
-		<-r.Context().Done()
-		if r.Context().Err() != nil {
-			o.log.Warn().
-				Err(r.Context().Err()).
-				Msg("webhook client disconnected, but processing continues")
+		if marker, ok := plat.(platform.DeliveryMarker); ok {
+			marker.MarkDelivered(r)
 		}
-	}()
-
-	if err := o.processPR(ctx, meta); err != nil {
-		o.log.Error().Err(err).Msg("failed to process PR")
-		http.Error(w, "processing error", http.StatusInternalServerError)
-		return
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("processed"))
 	}
-
-	o.log.Info().
-		Str("repo", meta.RepoOwner+"/"+meta.RepoName).
-		Int("pr", meta.PRNumber).
-		Msg("PR processing completed successfully")
-
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte("processed"))
 }
 
-func (o *Orchestrator) processPR(ctx context.Context, meta intm.PRMetadata) error {
+func (o *Orchestrator) processPR(ctx context.Context, plat platform.Platform, meta intm.PRMetadata) (err error) {
 	o.log.Info().
 		Str("repo", meta.RepoOwner+"/"+meta.RepoName).
 		Int("pr", meta.PRNumber).
 		Msg("starting PR processing pipeline")
 
-	_, err := PrepareRepository(ctx, o.log, &meta)
+	runID := o.reviewRec.Start(ctx, plat.Name(), meta.RepoOwner, meta.RepoName, meta.PRNumber, meta.HeadSHA)
+	defer func() { o.reviewRec.Finish(ctx, runID, err) }()
+
+	_, err = PrepareRepository(ctx, o.log, &meta)
 	if err != nil {
 		return err
 	}
@@ -128,45 +118,114 @@ func (o *Orchestrator) processPR(ctx context.Context, meta intm.PRMetadata) erro
 		Str("path", meta.LocalPath).
 		Msg("repository prepared")
 
-	desc, err := CallPRAgentDescribe(ctx, o.log, o.httpClient, o.cfg.PRAgentURL, meta)
+	descStart := time.Now()
+	desc, err := CallPRAgentDescribe(ctx, o.log, o.resilientClient, o.cfg().PRAgentURL, meta)
 	if err != nil {
 		return err
 	}
+	o.reviewRec.Stage(ctx, runID, reviewapi.StageDescribe, desc.DescriptionMarkdown, time.Since(descStart))
 	o.log.Debug().Msg("PR-Agent describe completed")
 
-	review, err := CallPRAgentReview(ctx, o.log, o.httpClient, o.cfg.PRAgentURL, meta, desc.DescriptionMarkdown)
+	reviewStart := time.Now()
+	review, err := CallPRAgentReview(ctx, o.log, o.resilientClient, o.cfg().PRAgentURL, meta, desc.DescriptionMarkdown)
 	if err != nil {
 		return err
 	}
+	o.reviewRec.Stage(ctx, runID, reviewapi.StageReview, review.ReviewMarkdown, time.Since(reviewStart))
 	o.log.Debug().Msg("PR-Agent review completed")
 
-	// CHANGED: Now calls cfg.SemgrepServiceURL instead of cfg.SemgrepMCPURL
-	semgrep, err := CallSemgrep(ctx, o.log, o.httpClient, o.cfg.SemgrepServiceURL, meta)
+	actions.MaskSecretsInText(meta.Body)
+
+	actions.Group("Static analysis scan")
+	semgrepStart := time.Now()
+	scanners := BuildScanners(o.cfg(), o.log, o.resilientClient)
+	semgrep, err := RunScanners(ctx, o.log, scanners, meta)
+	actions.EndGroup()
 	if err != nil {
 		return err
 	}
-	o.log.Debug().Msg("Semgrep scan completed")
+	o.reviewRec.Stage(ctx, runID, reviewapi.StageSemgrep, semgrep.FindingsMarkdown, time.Since(semgrepStart))
+	o.reviewRec.SARIF(ctx, runID, semgrep.Sarif)
+	o.log.Debug().Int("scanners", len(scanners)).Msg("static analysis scan completed")
+
+	actions.EmitFindings(semgrep.Findings)
+	if err := actions.WriteStepSummary(semgrep.FindingsMarkdown); err != nil {
+		o.log.Warn().Err(err).Msg("failed to write GitHub Actions step summary")
+	}
+
+	if semgrep.Sarif != "" && !o.cfg().DisableSarifUpload {
+		if o.cfg().GitHubToken == "" {
+			o.log.Warn().Msg("SARIF available but GITHUB_TOKEN is empty, skipping code-scanning upload")
+		} else {
+			uploader := intm.NewSarifUploader(o.httpClient, o.cfg().GitHubToken)
+			ref := "refs/heads/" + meta.SourceBranch
+			if err := uploader.Upload(ctx, o.log, meta.RepoOwner, meta.RepoName, meta.HeadSHA, ref, []byte(semgrep.Sarif)); err != nil {
+				o.log.Error().Err(err).Msg("failed to upload SARIF to GitHub code-scanning")
+			} else {
+				o.log.Info().Msg("SARIF uploaded to GitHub code-scanning")
+			}
+		}
+	}
 
+	var progressURL string
+	if base := o.cfg().PublicURL; base != "" {
+		progressURL = strings.TrimRight(base, "/") + "/api/v1/reviews/" + runID + "/progress/" + string(reviewapi.StageSummary)
+	}
+
+	summaryStart := time.Now()
 	summary, err := CallSummarizer(
 		ctx,
 		o.log,
-		o.httpClient,
-		o.cfg.SummarizerURL,
+		o.resilientClient,
+		o.cfg().SummarizerURL,
 		meta,
 		desc.DescriptionMarkdown,
 		review.ReviewMarkdown,
-		semgrep.FindingsMarkdown,
-		semgrep.Severity,
+		semgrep.Reports,
+		runID,
+		progressURL,
 	)
 	if err != nil {
 		return err
 	}
+	o.reviewRec.Stage(ctx, runID, reviewapi.StageSummary, summary.Markdown, time.Since(summaryStart))
+	if summary.Usage != nil {
+		o.reviewRec.Usage(ctx, runID, *summary.Usage)
+	}
 	o.log.Debug().Msg("Summarizer Agent completed")
 
-	if err := PostGitHubComment(ctx, o.log, o.httpClient, o.cfg.GitHubMCPURL, meta, summary.Markdown); err != nil {
+	if err = plat.PostComment(ctx, meta, summary.Markdown); err != nil {
 		return err
 	}
 
-	o.log.Info().Msg("GitHub PR comment posted successfully")
+	if inline, ok := plat.(platform.InlineReviewer); ok {
+		if comments := findingsToReviewComments(semgrep.Findings); len(comments) > 0 {
+			if err := inline.PostReview(ctx, meta, comments, summary.Markdown); err != nil {
+				o.log.Error().Err(err).Msg("failed to post inline review comments, summary comment already posted")
+			}
+		}
+	}
+
+	o.log.Info().Str("platform", plat.Name()).Msg("PR comment posted successfully")
 	return nil
 }
+
+// findingsToReviewComments maps structured Semgrep findings onto inline
+// GitHub review comments. Findings that can't be confidently anchored to
+// a line (no path or line number) are dropped here; the summary comment
+// already covers them.
+func findingsToReviewComments(findings []intm.Finding) []intm.ReviewComment {
+	comments := make([]intm.ReviewComment, 0, len(findings))
+	for _, f := range findings {
+		if f.Path == "" || f.Line <= 0 {
+			continue
+		}
+		comments = append(comments, intm.ReviewComment{
+			Path: f.Path,
+			Line: f.Line,
+			Side: "RIGHT",
+			Body: fmt.Sprintf("**%s** (%s): %s", f.RuleID, f.Severity, f.Message),
+		})
+	}
+	return comments
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
+
+	"github.com/rs/zerolog"
+)
+
+// sarifIngestScanner is a Scanner for tools that don't speak the
+// Semgrep-service protocol but already produce a SARIF 2.1.0 document
+// somewhere reachable over HTTP (Bandit and ESLint, run as separate CI
+// steps and published to a static URL, are the motivating cases). It GETs
+// the document and derives findings/severity/markdown from it directly,
+// so adding another such tool is a config entry, not new Go code.
+type sarifIngestScanner struct {
+	name   string
+	url    string
+	log    zerolog.Logger
+	client *httpx.Client
+}
+
+func (s *sarifIngestScanner) Name() string { return s.name }
+
+func (s *sarifIngestScanner) Scan(ctx context.Context, meta intm.PRMetadata) (*intm.SemgrepOut, error) {
+	resp, err := s.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create %s sarif request: %w", s.name, err)
+		}
+		return req, nil
+	})
+	if err != nil {
+		s.log.Error().Err(err).Str("scanner", s.name).Msg("failed to fetch sarif document")
+		return &intm.SemgrepOut{}, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s sarif body: %w", s.name, err)
+	}
+
+	out, err := sarifToSemgrepOut(body)
+	if err != nil {
+		s.log.Error().Err(err).Str("scanner", s.name).Msg("failed to parse sarif document")
+		return &intm.SemgrepOut{}, nil
+	}
+	return out, nil
+}
+
+// sarifResult is the subset of a SARIF 2.1.0 result object this ingester
+// understands: enough to anchor a Finding and derive a severity bucket.
+type sarifResult struct {
+	RuleID  string `json:"ruleId"`
+	Level   string `json:"level"` // "error", "warning", "note", "none"
+	Message struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine int `json:"startLine"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	} `json:"locations"`
+}
+
+type sarifRun struct {
+	Results []sarifResult `json:"results"`
+}
+
+type sarifDoc struct {
+	Runs []sarifRun `json:"runs"`
+}
+
+// sarifToSemgrepOut converts a raw SARIF document into a SemgrepOut: one
+// Finding per result, severity counts bucketed from SARIF's "level", and a
+// flat markdown table mirroring the other scanners' FindingsMarkdown shape.
+func sarifToSemgrepOut(raw []byte) (*intm.SemgrepOut, error) {
+	var doc sarifDoc
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("decode sarif: %w", err)
+	}
+
+	out := &intm.SemgrepOut{Sarif: string(raw)}
+
+	var md strings.Builder
+	for _, run := range doc.Runs {
+		for _, r := range run.Results {
+			sev := sarifLevelToSeverity(r.Level)
+			bumpSeverity(&out.Severity, sev)
+
+			path, line := "", 0
+			if len(r.Locations) > 0 {
+				path = r.Locations[0].PhysicalLocation.ArtifactLocation.URI
+				line = r.Locations[0].PhysicalLocation.Region.StartLine
+			}
+
+			out.Findings = append(out.Findings, intm.Finding{
+				RuleID:   r.RuleID,
+				Path:     path,
+				Line:     line,
+				Severity: sev,
+				Message:  r.Message.Text,
+			})
+			fmt.Fprintf(&md, "- **%s** `%s:%d` (%s): %s\n", r.RuleID, path, line, sev, r.Message.Text)
+		}
+	}
+	if md.Len() == 0 {
+		md.WriteString("No findings.\n")
+	}
+	out.FindingsMarkdown = md.String()
+
+	return out, nil
+}
+
+// sarifLevelToSeverity maps SARIF's four "level" values onto this repo's
+// five-bucket severity scale. SARIF has no "blocker" level, so only a
+// result's ruleId convention (not modeled here) could ever produce one;
+// ingested findings top out at "critical".
+func sarifLevelToSeverity(level string) string {
+	switch level {
+	case "error":
+		return "critical"
+	case "warning":
+		return "major"
+	case "note":
+		return "minor"
+	default:
+		return "info"
+	}
+}
+
+func bumpSeverity(s *intm.SemgrepSeveritySummary, severity string) {
+	switch severity {
+	case "blocker":
+		s.Blocker++
+	case "critical":
+		s.Critical++
+	case "major":
+		s.Major++
+	case "minor":
+		s.Minor++
+	default:
+		s.Info++
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+)
+
+// Scanner is a pluggable static-analysis backend. Semgrep is the reference
+// implementation; gosec, trivy and gitleaks all speak the same request/
+// response shape over their own service URL (see callScannerService).
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, meta intm.PRMetadata) (*intm.SemgrepOut, error)
+}
+
+// httpScanner is a Scanner backed by an HTTP service that implements the
+// Semgrep-service protocol (POST /scan, SemgrepServiceRequest/Response).
+type httpScanner struct {
+	name     string
+	url      string
+	log      zerolog.Logger
+	client   *httpx.Client
+	fullScan bool
+}
+
+func (s *httpScanner) Name() string { return s.name }
+
+func (s *httpScanner) Scan(ctx context.Context, meta intm.PRMetadata) (*intm.SemgrepOut, error) {
+	return callScannerService(ctx, s.log, s.client, s.name, s.url, meta, s.fullScan)
+}
+
+// BuildScanners constructs one Scanner per name in cfg.EnabledScanners,
+// picking its protocol from cfg.ScannerKind[name] ("http" by default,
+// "sarif", or "codeql"). Scanners missing what their kind requires (a
+// service URL, or a GitHub token) are skipped with a warning rather than
+// failing the whole pipeline.
+func BuildScanners(cfg intm.Config, log zerolog.Logger, client *httpx.Client) []Scanner {
+	scanners := make([]Scanner, 0, len(cfg.EnabledScanners))
+	for _, name := range cfg.EnabledScanners {
+		switch cfg.ScannerKind[name] {
+		case "codeql":
+			if cfg.GitHubToken == "" {
+				log.Warn().Str("scanner", name).Msg("codeql scanner enabled but GITHUB_TOKEN is empty, skipping")
+				continue
+			}
+			scanners = append(scanners, &codeQLScanner{token: cfg.GitHubToken, log: log, client: client})
+		case "sarif":
+			url := cfg.ScannerURLs[name]
+			if url == "" {
+				log.Warn().Str("scanner", name).Msg("scanner enabled but no sarif URL configured, skipping")
+				continue
+			}
+			scanners = append(scanners, &sarifIngestScanner{name: name, url: url, log: log, client: client})
+		default:
+			url := cfg.ScannerURLs[name]
+			if url == "" {
+				log.Warn().Str("scanner", name).Msg("scanner enabled but no service URL configured, skipping")
+				continue
+			}
+			scanners = append(scanners, &httpScanner{
+				name:     name,
+				url:      url,
+				log:      log,
+				client:   client,
+				fullScan: cfg.FullScan,
+			})
+		}
+	}
+	return scanners
+}
+
+// scanOutcome pairs a scanner's name with its result (or error) so results
+// can be merged deterministically after the fan-out completes.
+type scanOutcome struct {
+	name string
+	out  *intm.SemgrepOut
+	err  error
+}
+
+// RunScanners runs every scanner concurrently and merges their results into
+// a single SemgrepOut: markdown sections per scanner, summed severities,
+// concatenated findings and merged SARIF runs. It falls back to
+// generateHeuristicOutput when every scanner returned an error. An HTTP
+// scanner whose service call fails does not return an error: it returns
+// the heuristic output itself (see callScannerService), so its section
+// carries that generic advice even when other scanners succeed.
+func RunScanners(ctx context.Context, log zerolog.Logger, scanners []Scanner, meta intm.PRMetadata) (*intm.SemgrepOut, error) {
+	if len(scanners) == 0 {
+		log.Warn().Msg("no scanners enabled, using heuristic output")
+		return generateHeuristicOutput(), nil
+	}
+
+	outcomes := make([]scanOutcome, len(scanners))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, s := range scanners {
+		i, s := i, s
+		g.Go(func() error {
+			out, err := s.Scan(gctx, meta)
+			outcomes[i] = scanOutcome{name: s.Name(), out: out, err: err}
+			return nil // per-scanner errors don't cancel the other scanners
+		})
+	}
+	_ = g.Wait() // errors are carried in outcomes, not returned
+
+	return mergeScanOutcomes(log, outcomes), nil
+}
+
+// mergeScanOutcomes combines the per-scanner results into one SemgrepOut.
+func mergeScanOutcomes(log zerolog.Logger, outcomes []scanOutcome) *intm.SemgrepOut {
+	merged := &intm.SemgrepOut{}
+	var markdown string
+	var sarifDocs []string
+	succeeded := 0
+
+	for _, o := range outcomes {
+		if o.err != nil || o.out == nil {
+			log.Error().Err(o.err).Str("scanner", o.name).Msg("scanner failed")
+			continue
+		}
+		succeeded++
+
+		markdown += fmt.Sprintf("#### %s\n\n%s\n\n", o.name, o.out.FindingsMarkdown)
+
+		merged.Severity.Blocker += o.out.Severity.Blocker
+		merged.Severity.Critical += o.out.Severity.Critical
+		merged.Severity.Major += o.out.Severity.Major
+		merged.Severity.Minor += o.out.Severity.Minor
+		merged.Severity.Info += o.out.Severity.Info
+
+		merged.Findings = append(merged.Findings, o.out.Findings...)
+
+		if o.out.Sarif != "" {
+			sarifDocs = append(sarifDocs, o.out.Sarif)
+		}
+
+		report := intm.AnalyzerReport{
+			Name:     o.name,
+			Markdown: o.out.FindingsMarkdown,
+			Severity: o.out.Severity,
+		}
+		if o.out.Sarif != "" && json.Valid([]byte(o.out.Sarif)) {
+			report.Sarif = json.RawMessage(o.out.Sarif)
+		}
+		merged.Reports = append(merged.Reports, report)
+	}
+
+	if succeeded == 0 {
+		log.Error().Msg("all scanners failed, falling back to heuristic output")
+		return generateHeuristicOutput()
+	}
+
+	merged.FindingsMarkdown = markdown
+	merged.Sarif = mergeSarifDocs(log, sarifDocs)
+	return merged
+}
+
+// mergeSarifDocs concatenates the "runs" array of each per-scanner SARIF
+// document into a single SARIF 2.1.0 log, so one code-scanning upload
+// covers every scanner.
+func mergeSarifDocs(log zerolog.Logger, docs []string) string {
+	if len(docs) == 0 {
+		return ""
+	}
+	if len(docs) == 1 {
+		return docs[0]
+	}
+
+	var merged map[string]interface{}
+	var runs []interface{}
+
+	for i, doc := range docs {
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(doc), &parsed); err != nil {
+			log.Warn().Err(err).Msg("failed to parse SARIF document for merging, skipping it")
+			continue
+		}
+		if i == 0 || merged == nil {
+			merged = parsed
+		}
+		if docRuns, ok := parsed["runs"].([]interface{}); ok {
+			runs = append(runs, docRuns...)
+		}
+	}
+	if merged == nil {
+		return ""
+	}
+	merged["runs"] = runs
+
+	out, err := json.Marshal(merged)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to marshal merged SARIF document")
+		return docs[0]
+	}
+	return string(out)
+}
@@ -1,18 +1,20 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
 
 	"github.com/rs/zerolog"
 )
@@ -22,7 +24,8 @@ type SemgrepServiceRequest struct {
 	RepoPath string            `json:"repo_path"` // For reference only
 	RepoURL  string            `json:"repo_url,omitempty"`
 	Branch   string            `json:"branch,omitempty"`
-	Files    map[string]string `json:"files"` // filename -> content
+	BaseSHA  string            `json:"base_sha,omitempty"` // lets the service run --baseline-commit mode
+	Files    map[string]string `json:"files"`              // filename -> content
 }
 
 // SemgrepServiceResponse represents the response from Semgrep service
@@ -32,29 +35,61 @@ type SemgrepServiceResponse struct {
 	Severity         intm.SemgrepSeveritySummary `json:"severity"`
 	FindingsCount    int                         `json:"findings_count"`
 	ScanDuration     string                      `json:"scan_duration"`
+	Sarif            string                      `json:"sarif,omitempty"`
+	Findings         []intm.Finding              `json:"findings,omitempty"`
 	Error            string                      `json:"error,omitempty"`
 }
 
-// CallSemgrep now collects files and sends them via JSON
+// CallSemgrep collects files and streams them to the Semgrep service as JSON.
+// When fullScan is false (the default), only files touched by the PR diff
+// are collected; set FULL_SCAN=true to fall back to walking the whole repo.
 func CallSemgrep(
 	ctx context.Context,
 	log zerolog.Logger,
-	client *http.Client,
+	client *httpx.Client,
 	semgrepURL string,
 	meta intm.PRMetadata,
+	fullScan bool,
+) (*intm.SemgrepOut, error) {
+	return callScannerService(ctx, log, client, "semgrep", semgrepURL, meta, fullScan)
+}
+
+// callScannerService collects files and streams them to a scanner's HTTP
+// service as JSON. It's shared by every Scanner implementation (Semgrep,
+// gosec, trivy, gitleaks, ...) since they all speak the same request/
+// response shape over their own URL.
+func callScannerService(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *httpx.Client,
+	name string,
+	serviceURL string,
+	meta intm.PRMetadata,
+	fullScan bool,
 ) (*intm.SemgrepOut, error) {
 
 	if meta.LocalPath == "" {
-		return nil, fmt.Errorf("CallSemgrep: meta.LocalPath empty")
+		return nil, fmt.Errorf("callScannerService: meta.LocalPath empty")
 	}
 
 	log.Info().
 		Str("path", meta.LocalPath).
-		Str("semgrep_url", semgrepURL).
-		Msg("collecting files for Semgrep scan")
-
-	// Collect code files
-	files, err := collectCodeFiles(meta.LocalPath, log)
+		Str("scanner", name).
+		Str("scanner_url", serviceURL).
+		Bool("full_scan", fullScan).
+		Msg("collecting files for scan")
+
+	var (
+		files   map[string]string
+		baseSHA string
+		err     error
+	)
+
+	if fullScan {
+		files, err = collectCodeFiles(meta.LocalPath, log)
+	} else {
+		files, baseSHA, err = collectChangedFiles(ctx, meta, log)
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("failed to collect code files")
 		return generateHeuristicOutput(), nil // Fallback on error
@@ -67,36 +102,39 @@ func CallSemgrep(
 
 	log.Info().
 		Int("file_count", len(files)).
-		Msg("files collected, sending to Semgrep service")
+		Str("scanner", name).
+		Msg("files collected, sending to scanner service")
 
 	// Build request
 	reqPayload := SemgrepServiceRequest{
 		RepoPath: meta.LocalPath,
 		RepoURL:  fmt.Sprintf("https://github.com/%s/%s", meta.RepoOwner, meta.RepoName),
 		Branch:   meta.SourceBranch,
+		BaseSHA:  baseSHA,
 		Files:    files,
 	}
 
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(reqPayload); err != nil {
-		log.Error().Err(err).Msg("failed to encode semgrep request")
-		return generateHeuristicOutput(), nil // Fallback on error
-	}
-
-	// Create HTTP request with timeout
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, semgrepURL+"/scan", &buf)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to create semgrep request")
-		return generateHeuristicOutput(), nil
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	// Call Semgrep service
+	// Call the scanner service, retrying transient failures and failing
+	// fast into the heuristic fallback if the circuit for this host is open.
 	startTime := time.Now()
-	resp, err := client.Do(req)
+	resp, err := client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		// Stream the JSON body through a pipe instead of buffering the
+		// whole (possibly very large) diff payload in memory.
+		pr, pw := io.Pipe()
+		go func() {
+			err := json.NewEncoder(pw).Encode(reqPayload)
+			pw.CloseWithError(err)
+		}()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, serviceURL+"/scan", pr)
+		if err != nil {
+			return nil, fmt.Errorf("create %s request: %w", name, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
-		log.Error().Err(err).Msg("semgrep service http error")
+		log.Error().Err(err).Str("scanner", name).Msg("scanner service http error")
 		return generateHeuristicOutput(), nil // Fallback on error
 	}
 	defer resp.Body.Close()
@@ -107,20 +145,21 @@ func CallSemgrep(
 	if resp.StatusCode >= 300 {
 		log.Warn().
 			Int("status", resp.StatusCode).
-			Msg("semgrep service returned error status")
+			Str("scanner", name).
+			Msg("scanner service returned error status")
 		return generateHeuristicOutput(), nil
 	}
 
 	// Parse response
 	var semgrepResp SemgrepServiceResponse
 	if err := json.NewDecoder(resp.Body).Decode(&semgrepResp); err != nil {
-		log.Error().Err(err).Msg("failed to decode semgrep response")
+		log.Error().Err(err).Str("scanner", name).Msg("failed to decode scanner response")
 		return generateHeuristicOutput(), nil
 	}
 
 	// Check for service-level errors
 	if semgrepResp.Error != "" {
-		log.Warn().Str("error", semgrepResp.Error).Msg("semgrep service reported error")
+		log.Warn().Str("error", semgrepResp.Error).Str("scanner", name).Msg("scanner service reported error")
 		// Continue with results if available, otherwise fallback
 		if semgrepResp.FindingsMarkdown == "" {
 			return generateHeuristicOutput(), nil
@@ -128,6 +167,7 @@ func CallSemgrep(
 	}
 
 	log.Info().
+		Str("scanner", name).
 		Int("findings", semgrepResp.FindingsCount).
 		Dur("duration", duration).
 		Int("blocker", semgrepResp.Severity.Blocker).
@@ -135,36 +175,20 @@ func CallSemgrep(
 		Int("major", semgrepResp.Severity.Major).
 		Int("minor", semgrepResp.Severity.Minor).
 		Int("info", semgrepResp.Severity.Info).
-		Msg("semgrep scan completed")
+		Msg("scan completed")
 
 	return &intm.SemgrepOut{
 		FindingsMarkdown: semgrepResp.FindingsMarkdown,
 		Severity:         semgrepResp.Severity,
+		Sarif:            semgrepResp.Sarif,
+		Findings:         semgrepResp.Findings,
 	}, nil
 }
 
 // collectCodeFiles walks the directory and collects code files with their content
 func collectCodeFiles(repoPath string, log zerolog.Logger) (map[string]string, error) {
 	files := make(map[string]string)
-
-	// Supported extensions
-	supportedExts := map[string]bool{
-		".go":   true,
-		".py":   true,
-		".js":   true,
-		".ts":   true,
-		".jsx":  true,
-		".tsx":  true,
-		".java": true,
-		".rb":   true,
-		".php":  true,
-		".cs":   true,
-		".c":    true,
-		".cpp":  true,
-		".cc":   true,
-		".h":    true,
-		".hpp":  true,
-	}
+	supportedExts := supportedSourceExts()
 
 	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -215,6 +239,92 @@ func collectCodeFiles(repoPath string, log zerolog.Logger) (map[string]string, e
 	return files, nil
 }
 
+// collectChangedFiles restricts the uploaded file set to paths touched by
+// the PR, instead of walking the entire checked-out repo. It fetches the
+// target branch (the clone in PrepareRepository is a shallow, source-only
+// checkout) and diffs it against HeadSHA, then reads each changed file's
+// content at HeadSHA via `git show`.
+func collectChangedFiles(ctx context.Context, meta intm.PRMetadata, log zerolog.Logger) (map[string]string, string, error) {
+	if meta.TargetBranch == "" {
+		return nil, "", fmt.Errorf("collectChangedFiles: meta.TargetBranch empty")
+	}
+
+	if out, err := runGit(ctx, meta.LocalPath, "fetch", "--depth=1", "origin", meta.TargetBranch); err != nil {
+		return nil, "", fmt.Errorf("git fetch target branch: %w (%s)", err, out)
+	}
+
+	baseRef := "origin/" + meta.TargetBranch
+
+	baseSHA, err := runGit(ctx, meta.LocalPath, "rev-parse", baseRef)
+	if err != nil {
+		return nil, "", fmt.Errorf("git rev-parse base: %w", err)
+	}
+	baseSHA = strings.TrimSpace(baseSHA)
+
+	diffRange := fmt.Sprintf("%s...%s", baseRef, meta.HeadSHA)
+	out, err := runGit(ctx, meta.LocalPath, "diff", "--name-only", "--diff-filter=AMR", diffRange)
+	if err != nil {
+		return nil, "", fmt.Errorf("git diff --name-only: %w", err)
+	}
+
+	supportedExts := supportedSourceExts()
+
+	files := make(map[string]string)
+	for _, path := range strings.Split(strings.TrimSpace(out), "\n") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !supportedExts[strings.ToLower(filepath.Ext(path))] {
+			continue
+		}
+
+		content, err := runGit(ctx, meta.LocalPath, "show", meta.HeadSHA+":"+path)
+		if err != nil {
+			log.Warn().Err(err).Str("file", path).Msg("failed to read changed file via git show, skipping")
+			continue
+		}
+		files[path] = content
+	}
+
+	log.Info().
+		Int("file_count", len(files)).
+		Str("base_sha", baseSHA).
+		Msg("collected changed files from git diff")
+
+	return files, baseSHA, nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), err
+	}
+	return string(out), nil
+}
+
+func supportedSourceExts() map[string]bool {
+	return map[string]bool{
+		".go":   true,
+		".py":   true,
+		".js":   true,
+		".ts":   true,
+		".jsx":  true,
+		".tsx":  true,
+		".java": true,
+		".rb":   true,
+		".php":  true,
+		".cs":   true,
+		".c":    true,
+		".cpp":  true,
+		".cc":   true,
+		".h":    true,
+		".hpp":  true,
+	}
+}
+
 // generateHeuristicOutput returns generic security advice when Semgrep service fails
 func generateHeuristicOutput() *intm.SemgrepOut {
 	markdown := `### Security Analysis
@@ -239,8 +349,14 @@ func generateHeuristicOutput() *intm.SemgrepOut {
 **Recommendation:** Run a manual security review or local Semgrep scan for comprehensive analysis.
 `
 
+	severity := intm.SemgrepSeveritySummary{} // all zeros
 	return &intm.SemgrepOut{
 		FindingsMarkdown: markdown,
-		Severity:         intm.SemgrepSeveritySummary{}, // all zeros
+		Severity:         severity,
+		Reports: []intm.AnalyzerReport{{
+			Name:     "heuristic",
+			Markdown: markdown,
+			Severity: severity,
+		}},
 	}
 }
@@ -8,67 +8,80 @@ import (
 	"net/http"
 
 	intm "orchestrator/internal"
+	"orchestrator/internal/httpx"
+	"orchestrator/pkg/reviewapi"
 
 	"github.com/rs/zerolog"
 )
 
 type SummarizerOut struct {
 	Markdown string `json:"markdown"` // FIXED: was "final_markdown"
+
+	// Usage carries the underlying LLM call's token counts, recorded
+	// alongside the markdown artifacts by reviewapi.Recorder.
+	Usage *reviewapi.TokenUsage `json:"token_usage,omitempty"`
 }
 
-// CallSummarizer invokes the Summarizer Agent with description, review, semgrep.
+// CallSummarizer invokes the Summarizer Agent with description, review, and
+// one AnalyzerReport per static-analysis scanner that ran (Semgrep, and
+// whatever else BuildScanners constructed) -- the summarizer renders one
+// section per report, so adding another analyzer here needs no change on
+// its side. runID and progressURL (both may be empty) let the summarizer
+// checkpoint its streamed Anthropic response and, if progressURL is set,
+// POST partial markdown back to it as it streams; see pkg/reviewapi's
+// progress route.
 func CallSummarizer(
 	ctx context.Context,
 	log zerolog.Logger,
-	client *http.Client,
+	client *httpx.Client,
 	baseURL string,
 	meta intm.PRMetadata,
 	descriptionMarkdown string,
 	reviewMarkdown string,
-	semgrepMarkdown string,
-	semgrepSeverity intm.SemgrepSeveritySummary,
+	reports []intm.AnalyzerReport,
+	runID string,
+	progressURL string,
 ) (*SummarizerOut, error) {
 
 	payload := struct {
-		PR                  intm.PRMetadata             `json:"pr"`
-		DescriptionMarkdown string                      `json:"description_markdown"`
-		ReviewMarkdown      string                      `json:"review_markdown"`
-		SemgrepMarkdown     string                      `json:"semgrep_markdown"`
-		SemgrepSeverity     intm.SemgrepSeveritySummary `json:"semgrep_severity"`
+		PR                  intm.PRMetadata       `json:"pr"`
+		DescriptionMarkdown string                `json:"description_markdown"`
+		ReviewMarkdown      string                `json:"review_markdown"`
+		AnalyzerReports     []intm.AnalyzerReport `json:"analyzer_reports"`
+		RunID               string                `json:"run_id,omitempty"`
+		ProgressURL         string                `json:"progress_url,omitempty"`
 	}{
 		PR:                  meta,
 		DescriptionMarkdown: descriptionMarkdown,
 		ReviewMarkdown:      reviewMarkdown,
-		SemgrepMarkdown:     semgrepMarkdown,
-		SemgrepSeverity:     semgrepSeverity,
+		AnalyzerReports:     reports,
+		RunID:               runID,
+		ProgressURL:         progressURL,
 	}
 
 	log.Debug().
 		Str("url", baseURL).
-		Interface("severity", semgrepSeverity).
+		Int("analyzer_reports", len(reports)).
 		Msg("calling Summarizer Agent")
 
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(payload); err != nil {
-		return nil, fmt.Errorf("encode summarizer payload: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, &buf)
+	encoded, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("create summarizer request: %w", err)
+		return nil, fmt.Errorf("encode summarizer payload: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, bytes.NewReader(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("create summarizer request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("summarizer http error: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("summarizer returned status %d", resp.StatusCode)
-	}
-
 	// Decode using the fixed SummarizerOut type
 	var out SummarizerOut
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
@@ -0,0 +1,155 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// anthropicBackend talks to the Anthropic Messages API, which uses a
+// different request shape (top-level "system" field, content blocks in
+// the response) and streams "content_block_delta" events rather than
+// OpenAI-style "choices[].delta".
+type anthropicBackend struct {
+	apiKey string
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system"`
+	Messages  []anthropicMessage `json:"messages"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// anthropicStreamEvent covers the subset of Anthropic's SSE event shapes
+// needed to assemble text tokens.
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+}
+
+func (b *anthropicBackend) request(req Request, stream bool) anthropicRequest {
+	return anthropicRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		System:    req.System,
+		Messages:  []anthropicMessage{{Role: "user", Content: req.User}},
+		Stream:    stream,
+	}
+}
+
+func (b *anthropicBackend) newRequest(ctx context.Context, req Request, stream bool) (*http.Request, error) {
+	if b.apiKey == "" {
+		return nil, fmt.Errorf("missing ANTHROPIC_API_KEY")
+	}
+
+	body, _ := json.Marshal(b.request(req, stream))
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("x-api-key", b.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	return httpReq, nil
+}
+
+func (b *anthropicBackend) Generate(ctx context.Context, req Request) (Response, error) {
+	httpReq, err := b.newRequest(ctx, req, false)
+	if err != nil {
+		return Response{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(resp.Body)
+		return Response{}, fmt.Errorf("anthropic error: %d: %s", resp.StatusCode, errBody)
+	}
+
+	var parsed anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Response{}, err
+	}
+	if len(parsed.Content) == 0 {
+		return Response{}, fmt.Errorf("anthropic returned no content")
+	}
+	return Response{Text: parsed.Content[0].Text}, nil
+}
+
+func (b *anthropicBackend) GenerateStream(ctx context.Context, req Request, onToken func(string)) (Response, error) {
+	httpReq, err := b.newRequest(ctx, req, true)
+	if err != nil {
+		return Response{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(resp.Body)
+		return Response{}, fmt.Errorf("anthropic error: %d: %s", resp.StatusCode, errBody)
+	}
+
+	var full strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return Response{Text: full.String()}, ctx.Err()
+		default:
+		}
+
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var evt anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &evt); err != nil {
+			continue
+		}
+		if evt.Type != "content_block_delta" || evt.Delta.Text == "" {
+			continue
+		}
+		full.WriteString(evt.Delta.Text)
+		if onToken != nil {
+			onToken(evt.Delta.Text)
+		}
+	}
+	return Response{Text: full.String()}, scanner.Err()
+}
@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// azureBackend talks to an Azure OpenAI deployment. Unlike the public
+// OpenAI API, the model is selected by the deployment name baked into the
+// URL, auth uses the api-key header instead of a bearer token, and the API
+// version is a required query parameter. Any deployment name is accepted;
+// Azure itself enforces which models a deployment maps to.
+type azureBackend struct {
+	endpoint   string // e.g. https://my-resource.openai.azure.com
+	deployment string
+	apiVersion string
+	apiKey     string
+}
+
+func (b *azureBackend) url() string {
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s", b.endpoint, b.deployment, b.apiVersion)
+}
+
+func (b *azureBackend) do(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	if b.endpoint == "" || b.deployment == "" || b.apiKey == "" {
+		return nil, fmt.Errorf("azure backend misconfigured: need AZURE_OPENAI_ENDPOINT, AZURE_OPENAI_DEPLOYMENT and AZURE_OPENAI_API_KEY")
+	}
+
+	body, _ := json.Marshal(openAIChatRequest{
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Messages:    chatMessages(req),
+		Stream:      stream,
+	})
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", b.url(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("api-key", b.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("azure openai error: %d: %s", resp.StatusCode, errBody)
+	}
+	return resp, nil
+}
+
+func (b *azureBackend) Generate(ctx context.Context, req Request) (Response, error) {
+	resp, err := b.do(ctx, req, false)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var parsed openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Response{}, err
+	}
+	if len(parsed.Choices) == 0 {
+		return Response{}, fmt.Errorf("empty Azure OpenAI response")
+	}
+	return Response{Text: parsed.Choices[0].Message.Content}, nil
+}
+
+func (b *azureBackend) GenerateStream(ctx context.Context, req Request, onToken func(string)) (Response, error) {
+	resp, err := b.do(ctx, req, true)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	text, err := decodeOpenAISSEStream(ctx, resp.Body, onToken)
+	return Response{Text: text}, err
+}
@@ -0,0 +1,90 @@
+// Package backend abstracts the LLM provider behind the PR agent's
+// completion calls, so teams can point the reviewer at OpenAI, Azure
+// OpenAI, Anthropic, or a locally-hosted model server without changing the
+// orchestrator contract (PRAgentDescribeOut/PRAgentReviewOut stay the
+// same either way).
+package backend
+
+import (
+	"context"
+	"fmt"
+)
+
+// Request is a single completion request, provider-agnostic.
+type Request struct {
+	Model       string
+	Temperature float32
+	MaxTokens   int
+	System      string
+	User        string
+}
+
+// Response is the assembled completion text.
+type Response struct {
+	Text string
+	// Usage is best-effort: only backends that get token counts back from
+	// their provider (currently OpenAI) populate it, so callers must treat
+	// a zero Usage as "unknown" rather than "zero tokens spent".
+	Usage Usage
+}
+
+// Usage carries token accounting for a single completion, when the
+// provider reports it.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Backend is an LLM provider capable of non-streaming and token-streaming
+// completions.
+type Backend interface {
+	// Generate runs a completion and returns the full text.
+	Generate(ctx context.Context, req Request) (Response, error)
+	// GenerateStream runs a completion, invoking onToken for every chunk as
+	// it arrives, and returns the full assembled text once the stream ends.
+	// onToken may be nil, in which case GenerateStream behaves like Generate.
+	GenerateStream(ctx context.Context, req Request, onToken func(string)) (Response, error)
+}
+
+// Config carries the LLM_BACKEND selection and every backend-specific env
+// var loadConfig knows how to read.
+type Config struct {
+	Name string // openai | azure | anthropic | grpc
+
+	// OpenAI
+	OpenAIKey string
+
+	// Azure OpenAI
+	AzureEndpoint   string
+	AzureDeployment string
+	AzureAPIVersion string
+	AzureAPIKey     string
+
+	// Anthropic
+	AnthropicKey string
+
+	// gRPC (locally-hosted model server)
+	GRPCAddr string
+}
+
+// New constructs the Backend selected by cfg.Name, defaulting to OpenAI
+// when unset so existing deployments don't need to set LLM_BACKEND.
+func New(cfg Config) (Backend, error) {
+	switch cfg.Name {
+	case "", "openai":
+		return &openAIBackend{apiKey: cfg.OpenAIKey}, nil
+	case "azure":
+		return &azureBackend{
+			endpoint:   cfg.AzureEndpoint,
+			deployment: cfg.AzureDeployment,
+			apiVersion: cfg.AzureAPIVersion,
+			apiKey:     cfg.AzureAPIKey,
+		}, nil
+	case "anthropic":
+		return &anthropicBackend{apiKey: cfg.AnthropicKey}, nil
+	case "grpc":
+		return newGRPCBackend(cfg.GRPCAddr)
+	default:
+		return nil, fmt.Errorf("unknown LLM_BACKEND %q (want openai, azure, anthropic or grpc)", cfg.Name)
+	}
+}
@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"pr-agent/backend/pb"
+)
+
+// grpcBackend adapts a locally-hosted model server speaking the Backend
+// gRPC service (backend/pb/backend.proto) to the Backend interface, so
+// teams can run the PR reviewer against on-prem models without touching
+// the orchestrator contract. The service only exposes a streaming RPC;
+// Generate drains the stream and returns the assembled text.
+type grpcBackend struct {
+	addr string
+}
+
+func newGRPCBackend(addr string) (Backend, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("grpc backend misconfigured: need GRPC_BACKEND_ADDR")
+	}
+	return &grpcBackend{addr: addr}, nil
+}
+
+func (b *grpcBackend) dial(ctx context.Context) (pb.BackendClient, func(), error) {
+	conn, err := grpc.DialContext(ctx, b.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("dial grpc backend %s: %w", b.addr, err)
+	}
+	return pb.NewBackendClient(conn), func() { conn.Close() }, nil
+}
+
+func (b *grpcBackend) Generate(ctx context.Context, req Request) (Response, error) {
+	return b.GenerateStream(ctx, req, nil)
+}
+
+func (b *grpcBackend) GenerateStream(ctx context.Context, req Request, onToken func(string)) (Response, error) {
+	client, closeConn, err := b.dial(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+	defer closeConn()
+
+	stream, err := client.GenerateStream(ctx, &pb.GenerateRequest{
+		Model:       req.Model,
+		Prompt:      req.System + "\n\n" + req.User,
+		Temperature: req.Temperature,
+		Tokens:      int32(req.MaxTokens),
+	})
+	if err != nil {
+		return Response{}, fmt.Errorf("grpc backend call: %w", err)
+	}
+
+	var full strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Response{Text: full.String()}, fmt.Errorf("grpc backend stream: %w", err)
+		}
+		if chunk.Token != "" {
+			full.WriteString(chunk.Token)
+			if onToken != nil {
+				onToken(chunk.Token)
+			}
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	return Response{Text: full.String()}, nil
+}
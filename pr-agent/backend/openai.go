@@ -0,0 +1,198 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// openAIValidModels is the allowlist for the public OpenAI API specifically
+// (Azure deployments and Anthropic/gRPC models have their own naming and
+// don't go through this check).
+var openAIValidModels = map[string]bool{
+	"gpt-4o":       true,
+	"gpt-4o-mini":  true,
+	"gpt-4.1":      true,
+	"gpt-4.1-mini": true,
+}
+
+type openAIBackend struct {
+	apiKey string
+}
+
+type openAIChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model       string              `json:"model,omitempty"`
+	Temperature float32             `json:"temperature"`
+	MaxTokens   int                 `json:"max_tokens"`
+	Messages    []openAIChatMessage `json:"messages"`
+	Stream      bool                `json:"stream,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIChatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// openAIStreamChunk is one "data: {...}" line of an OpenAI-shaped
+// text/event-stream completion (also used by the Azure backend, which
+// speaks the same wire format).
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+func (b *openAIBackend) Generate(ctx context.Context, req Request) (Response, error) {
+	if !openAIValidModels[req.Model] {
+		return Response{}, fmt.Errorf("invalid model: %s", req.Model)
+	}
+	if b.apiKey == "" {
+		return Response{}, fmt.Errorf("missing OPENAI_API_KEY")
+	}
+
+	body, _ := json.Marshal(openAIChatRequest{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Messages:    chatMessages(req),
+	})
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", strings.NewReader(string(body)))
+	if err != nil {
+		return Response{}, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+b.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(resp.Body)
+		return Response{}, fmt.Errorf("openai error: %d: %s", resp.StatusCode, errBody)
+	}
+
+	var parsed openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Response{}, err
+	}
+	if len(parsed.Choices) == 0 {
+		return Response{}, fmt.Errorf("empty OpenAI response")
+	}
+	return Response{
+		Text: parsed.Choices[0].Message.Content,
+		Usage: Usage{
+			PromptTokens:     parsed.Usage.PromptTokens,
+			CompletionTokens: parsed.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+func (b *openAIBackend) GenerateStream(ctx context.Context, req Request, onToken func(string)) (Response, error) {
+	if !openAIValidModels[req.Model] {
+		return Response{}, fmt.Errorf("invalid model: %s", req.Model)
+	}
+	if b.apiKey == "" {
+		return Response{}, fmt.Errorf("missing OPENAI_API_KEY")
+	}
+
+	body, _ := json.Marshal(openAIChatRequest{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Messages:    chatMessages(req),
+		Stream:      true,
+	})
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", strings.NewReader(string(body)))
+	if err != nil {
+		return Response{}, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+b.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(resp.Body)
+		return Response{}, fmt.Errorf("openai error: %d: %s", resp.StatusCode, errBody)
+	}
+
+	text, err := decodeOpenAISSEStream(ctx, resp.Body, onToken)
+	return Response{Text: text}, err
+}
+
+func chatMessages(req Request) []openAIChatMessage {
+	return []openAIChatMessage{
+		{Role: "system", Content: req.System},
+		{Role: "user", Content: req.User},
+	}
+}
+
+// decodeOpenAISSEStream reads an OpenAI-shaped text/event-stream body,
+// invoking onToken for every delta chunk and returning the assembled text
+// once it sees the "[DONE]" sentinel, EOF, or ctx is cancelled. Shared by
+// the OpenAI and Azure OpenAI backends, which speak the same wire format.
+func decodeOpenAISSEStream(ctx context.Context, r io.Reader, onToken func(string)) (string, error) {
+	var full strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return full.String(), ctx.Err()
+		default:
+		}
+
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		token := chunk.Choices[0].Delta.Content
+		if token == "" {
+			continue
+		}
+		full.WriteString(token)
+		if onToken != nil {
+			onToken(token)
+		}
+	}
+	return full.String(), scanner.Err()
+}
@@ -0,0 +1,66 @@
+// Package cache stores LLM responses keyed by the content of the request
+// that produced them, so re-running describe/review against an unchanged
+// diff (common during CI retries) doesn't re-issue an identical, billable
+// LLM call. It mirrors the backend package's shape: an interface plus a
+// Config-driven constructor that picks an in-memory or Redis-backed
+// implementation.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Entry is one cached LLM response, tagged with the PR it came from so a
+// superseded PR's entries can be found and dropped by Invalidate without
+// knowing their content keys.
+type Entry struct {
+	Markdown  string
+	RepoOwner string
+	RepoName  string
+	PRNumber  int
+	HeadSHA   string
+}
+
+// Cache stores Entry values behind content-addressed keys built by Key.
+type Cache interface {
+	// Get returns the cached entry for key, if present and unexpired.
+	Get(ctx context.Context, key string) (Entry, bool, error)
+	// Set stores entry under key for ttl.
+	Set(ctx context.Context, key string, entry Entry, ttl time.Duration) error
+	// Invalidate drops every entry tagged with the given repo/PR, returning
+	// how many entries were removed.
+	Invalidate(ctx context.Context, repoOwner, repoName string, prNumber int) (int, error)
+}
+
+// Config selects and sizes the cache implementation. RedisURL, when set,
+// takes precedence over the in-memory LRU.
+type Config struct {
+	MaxEntries int    // in-memory LRU size; ignored when RedisURL is set
+	RedisURL   string // CACHE_REDIS_URL, e.g. "redis://cache:6379/0"
+}
+
+// New constructs the Cache selected by cfg, defaulting to an in-memory LRU
+// so deployments that don't set CACHE_REDIS_URL still get caching.
+func New(cfg Config) (Cache, error) {
+	if cfg.RedisURL != "" {
+		return newRedisCache(cfg.RedisURL)
+	}
+	return newMemoryCache(cfg.MaxEntries), nil
+}
+
+// Key builds the content-addressed cache key for a completion request:
+// sha256(mode | model | sys | user).
+func Key(mode, model, sys, user string) string {
+	h := sha256.New()
+	h.Write([]byte(mode))
+	h.Write([]byte{'|'})
+	h.Write([]byte(model))
+	h.Write([]byte{'|'})
+	h.Write([]byte(sys))
+	h.Write([]byte{'|'})
+	h.Write([]byte(user))
+	return hex.EncodeToString(h.Sum(nil))
+}
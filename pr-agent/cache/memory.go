@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryCache is a size-bounded, TTL'd LRU. It's the default backend so
+// deployments that don't set CACHE_REDIS_URL still get caching, at the
+// cost of a cold cache on every pr-agent restart.
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List // front = most recently used
+}
+
+type memoryItem struct {
+	key       string
+	entry     Entry
+	expiresAt time.Time
+}
+
+func newMemoryCache(maxEntries int) *memoryCache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &memoryCache{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) (Entry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+
+	item := el.Value.(*memoryItem)
+	if time.Now().After(item.expiresAt) {
+		c.removeLocked(el)
+		return Entry{}, false, nil
+	}
+
+	c.order.MoveToFront(el)
+	return item.entry, true, nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, entry Entry, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+
+	if el, ok := c.items[key]; ok {
+		item := el.Value.(*memoryItem)
+		item.entry = entry
+		item.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	el := c.order.PushFront(&memoryItem{key: key, entry: entry, expiresAt: expiresAt})
+	c.items[key] = el
+
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.removeLocked(oldest)
+	}
+	return nil
+}
+
+func (c *memoryCache) Invalidate(ctx context.Context, repoOwner, repoName string, prNumber int) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var dropped int
+	for key, el := range c.items {
+		item := el.Value.(*memoryItem)
+		if item.entry.RepoOwner == repoOwner && item.entry.RepoName == repoName && item.entry.PRNumber == prNumber {
+			c.order.Remove(el)
+			delete(c.items, key)
+			dropped++
+		}
+	}
+	return dropped, nil
+}
+
+func (c *memoryCache) removeLocked(el *list.Element) {
+	item := el.Value.(*memoryItem)
+	c.order.Remove(el)
+	delete(c.items, item.key)
+}
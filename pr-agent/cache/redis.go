@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisCache stores entries as JSON under cacheKey(key), and keeps a
+// per-repo/PR set of those keys under indexKey so Invalidate can flush
+// everything for a superseded PR without a SCAN over the whole keyspace.
+type redisCache struct {
+	client *redis.Client
+}
+
+func newRedisCache(url string) (*redisCache, error) {
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("parse CACHE_REDIS_URL: %w", err)
+	}
+	return &redisCache{client: redis.NewClient(opt)}, nil
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) (Entry, bool, error) {
+	raw, err := c.client.Get(ctx, cacheKey(key)).Bytes()
+	if err == redis.Nil {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return Entry{}, false, err
+	}
+	return entry, true, nil
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, entry Entry, ttl time.Duration) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := c.client.Set(ctx, cacheKey(key), raw, ttl).Err(); err != nil {
+		return err
+	}
+	// The index set has no TTL of its own; stale members just miss on Get
+	// and are harmless until the next Invalidate for that repo/PR.
+	return c.client.SAdd(ctx, indexKey(entry.RepoOwner, entry.RepoName, entry.PRNumber), key).Err()
+}
+
+func (c *redisCache) Invalidate(ctx context.Context, repoOwner, repoName string, prNumber int) (int, error) {
+	idx := indexKey(repoOwner, repoName, prNumber)
+
+	keys, err := c.client.SMembers(ctx, idx).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	full := make([]string, len(keys))
+	for i, k := range keys {
+		full[i] = cacheKey(k)
+	}
+	// Del's count excludes index members whose entries already expired.
+	removed, err := c.client.Del(ctx, full...).Result()
+	if err != nil {
+		return 0, err
+	}
+	if err := c.client.Del(ctx, idx).Err(); err != nil {
+		return 0, err
+	}
+	return int(removed), nil
+}
+
+func cacheKey(key string) string {
+	return "pragent:cache:" + key
+}
+
+func indexKey(repoOwner, repoName string, prNumber int) string {
+	return fmt.Sprintf("pragent:index:%s/%s#%d", repoOwner, repoName, prNumber)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pr-agent/cache"
+)
+
+/* =====================================================================================
+   CACHE ADMIN
+
+   /cache/invalidate lets CI flush a superseded PR's cached describe/review
+   responses (e.g. after a force-push changes HeadSHA) instead of waiting
+   out the TTL.
+===================================================================================== */
+
+// cacheInvalidateHandler drops every cache entry tagged with the repo/PR
+// given by the "repo" (owner/name) and "pr" query params.
+func cacheInvalidateHandler(rc cache.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repo := r.URL.Query().Get("repo")
+		owner, name, ok := strings.Cut(repo, "/")
+		if !ok {
+			http.Error(w, `"repo" must be "owner/name"`, http.StatusBadRequest)
+			return
+		}
+
+		prNumber, err := strconv.Atoi(r.URL.Query().Get("pr"))
+		if err != nil {
+			http.Error(w, `"pr" must be an integer`, http.StatusBadRequest)
+			return
+		}
+
+		dropped, err := rc.Invalidate(r.Context(), owner, name, prNumber)
+		if err != nil {
+			log.Printf("❌ cache: invalidate %s/%s#%d failed: %v", owner, name, prNumber, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("🗑️ cache: invalidated %d entries for %s/%s#%d", dropped, owner, name, prNumber)
+		json.NewEncoder(w).Encode(map[string]int{"invalidated": dropped})
+	}
+}
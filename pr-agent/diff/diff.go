@@ -0,0 +1,144 @@
+// Package diff turns a PR's unified diff into token-budgeted chunks so a
+// large review can be processed as a bounded map-reduce instead of one
+// prompt that blows past the model's context window.
+package diff
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Hunk is one "@@ ... @@" block of a unified diff, scoped to a single file.
+type Hunk struct {
+	Path      string // file path as it appears in the diff ("b/" side)
+	StartLine int    // first line number of the hunk in the new file
+	Text      string // the hunk header plus its body lines
+}
+
+// Chunk groups hunks whose combined size fits within a token budget.
+type Chunk struct {
+	Hunks           []Hunk
+	EstimatedTokens int
+}
+
+// Collect fetches the target branch and runs
+// `git diff --unified=3 origin/<target>...HEAD` inside repoPath, returning
+// the raw unified diff between the PR's base and its current checkout.
+func Collect(ctx context.Context, repoPath, sourceBranch, targetBranch string) (string, error) {
+	if targetBranch == "" {
+		return "", fmt.Errorf("diff.Collect: targetBranch empty")
+	}
+	if repoPath == "" {
+		return "", fmt.Errorf("diff.Collect: repoPath empty")
+	}
+
+	fetch := exec.CommandContext(ctx, "git", "fetch", "--depth=1", "origin", targetBranch)
+	fetch.Dir = repoPath
+	if out, err := fetch.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git fetch target branch: %w (%s)", err, out)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "diff", "--unified=3", "origin/"+targetBranch+"...HEAD")
+	cmd.Dir = repoPath
+
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git diff: %w (%s)", err, stderr.String())
+	}
+	return out.String(), nil
+}
+
+var (
+	fileHeaderRe = regexp.MustCompile(`^\+\+\+ b/(.+)$`)
+	hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+)
+
+// SplitHunks parses a unified diff into per-file hunks. Hunks on the
+// "/dev/null" side of a deleted file are skipped since there's no new-file
+// line to anchor a comment to.
+func SplitHunks(diffText string) []Hunk {
+	var hunks []Hunk
+	var currentPath string
+	var cur *Hunk
+
+	flush := func() {
+		if cur != nil {
+			hunks = append(hunks, *cur)
+			cur = nil
+		}
+	}
+
+	for _, line := range strings.Split(diffText, "\n") {
+		// A new file section starts with no path until its "+++ b/" line,
+		// so a deleted file ("+++ /dev/null") keeps currentPath empty.
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			currentPath = ""
+			continue
+		}
+		if m := fileHeaderRe.FindStringSubmatch(line); m != nil {
+			flush()
+			currentPath = m[1]
+			continue
+		}
+		if m := hunkHeaderRe.FindStringSubmatch(line); m != nil {
+			flush()
+			if currentPath == "" {
+				continue
+			}
+			startLine, _ := strconv.Atoi(m[1])
+			cur = &Hunk{Path: currentPath, StartLine: startLine, Text: line + "\n"}
+			continue
+		}
+		if cur != nil {
+			cur.Text += line + "\n"
+		}
+	}
+	flush()
+
+	return hunks
+}
+
+// ChunkHunks groups hunks into chunks whose estimated size stays within
+// tokenBudget (falling back to 6000 if unset), preserving diff order.
+func ChunkHunks(hunks []Hunk, tokenBudget int, estimateTokens func(string) int) []Chunk {
+	if tokenBudget <= 0 {
+		tokenBudget = 6000
+	}
+
+	var chunks []Chunk
+	var cur Chunk
+
+	for _, h := range hunks {
+		size := estimateTokens(h.Text)
+		if len(cur.Hunks) > 0 && cur.EstimatedTokens+size > tokenBudget {
+			chunks = append(chunks, cur)
+			cur = Chunk{}
+		}
+		cur.Hunks = append(cur.Hunks, h)
+		cur.EstimatedTokens += size
+	}
+	if len(cur.Hunks) > 0 {
+		chunks = append(chunks, cur)
+	}
+
+	return chunks
+}
+
+// Render joins a chunk's hunks back into prompt-ready text, with each hunk
+// prefixed by a "path:line" anchor so LLM comments can be mapped back onto
+// the diff.
+func (c Chunk) Render() string {
+	var sb strings.Builder
+	for _, h := range c.Hunks {
+		fmt.Fprintf(&sb, "### %s:%d\n%s\n", h.Path, h.StartLine, h.Text)
+	}
+	return sb.String()
+}
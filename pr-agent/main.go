@@ -6,12 +6,16 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"pr-agent/backend"
+	"pr-agent/cache"
 )
 
 /* =====================================================================================
@@ -21,12 +25,23 @@ import (
 type Config struct {
 	ListenAddr     string
 	LogLevel       string
-	OpenAIKey      string
 	Model          string
 	Timeout        int // Timeout in seconds
-	MaxTokens      int // Max tokens for OpenAI response
+	MaxTokens      int // Max tokens for LLM response
 	PromptDescribe string
 	PromptReview   string
+	SemgrepConfig  string // ruleset passed to `semgrep --config`, e.g. "auto" or "p/ci"
+
+	ReviewChunkTokenBudget int // max estimated tokens per diff chunk in the map-reduce review
+	MaxParallelLLM         int // bounded worker pool size for the map-reduce review
+
+	MetricsAuthToken string // shared secret required on /metrics when set
+
+	CacheMaxEntries int           // in-memory LRU size when CacheRedisURL is unset
+	CacheTTL        time.Duration // how long a cached response stays valid
+	CacheRedisURL   string        // CACHE_REDIS_URL; unset keeps the cache in-process
+
+	Backend backend.Config // LLM_BACKEND selection and its provider-specific env
 }
 
 func loadConfig() Config {
@@ -56,33 +71,71 @@ func loadConfig() Config {
 		model = "gpt-4o"
 	}
 
+	// Default 6000 tokens per diff chunk in the map-reduce review.
+	chunkBudget := 6000
+	if cb := os.Getenv("REVIEW_CHUNK_TOKEN_BUDGET"); cb != "" {
+		if parsed, err := strconv.Atoi(cb); err == nil {
+			chunkBudget = parsed
+		}
+	}
+
+	// Default 3 concurrent LLM calls while mapping over diff chunks.
+	maxParallelLLM := 3
+	if mp := os.Getenv("MAX_PARALLEL_LLM"); mp != "" {
+		if parsed, err := strconv.Atoi(mp); err == nil {
+			maxParallelLLM = parsed
+		}
+	}
+
+	// Default 1000 entries in the in-memory LRU.
+	cacheMaxEntries := 1000
+	if cm := os.Getenv("CACHE_MAX_ENTRIES"); cm != "" {
+		if parsed, err := strconv.Atoi(cm); err == nil {
+			cacheMaxEntries = parsed
+		}
+	}
+
+	// Default 7 days (168 hours).
+	cacheTTLHours := 168
+	if ct := os.Getenv("CACHE_TTL_HOURS"); ct != "" {
+		if parsed, err := strconv.Atoi(ct); err == nil {
+			cacheTTLHours = parsed
+		}
+	}
+
 	return Config{
-		ListenAddr:     addr,
-		LogLevel:       os.Getenv("LOG_LEVEL"),
-		OpenAIKey:      os.Getenv("OPENAI_API_KEY"),
-		Model:          model,
-		Timeout:        timeout,
-		MaxTokens:      maxTokens,
-		PromptDescribe: os.Getenv("PR_AGENT_PROMPT_DESCRIBE"),
-		PromptReview:   os.Getenv("PR_AGENT_PROMPT_REVIEW"),
+		ListenAddr:             addr,
+		LogLevel:               os.Getenv("LOG_LEVEL"),
+		Model:                  model,
+		Timeout:                timeout,
+		MaxTokens:              maxTokens,
+		PromptDescribe:         os.Getenv("PR_AGENT_PROMPT_DESCRIBE"),
+		PromptReview:           os.Getenv("PR_AGENT_PROMPT_REVIEW"),
+		SemgrepConfig:          envOr("SEMGREP_CONFIG", "auto"),
+		ReviewChunkTokenBudget: chunkBudget,
+		MaxParallelLLM:         maxParallelLLM,
+		MetricsAuthToken:       os.Getenv("METRICS_AUTH_TOKEN"),
+		CacheMaxEntries:        cacheMaxEntries,
+		CacheTTL:               time.Duration(cacheTTLHours) * time.Hour,
+		CacheRedisURL:          os.Getenv("CACHE_REDIS_URL"),
+		Backend: backend.Config{
+			Name:            os.Getenv("LLM_BACKEND"), // openai | azure | anthropic | grpc
+			OpenAIKey:       os.Getenv("OPENAI_API_KEY"),
+			AzureEndpoint:   os.Getenv("AZURE_OPENAI_ENDPOINT"),
+			AzureDeployment: os.Getenv("AZURE_OPENAI_DEPLOYMENT"),
+			AzureAPIVersion: envOr("AZURE_OPENAI_API_VERSION", "2024-02-15-preview"),
+			AzureAPIKey:     os.Getenv("AZURE_OPENAI_API_KEY"),
+			AnthropicKey:    os.Getenv("ANTHROPIC_API_KEY"),
+			GRPCAddr:        os.Getenv("GRPC_BACKEND_ADDR"),
+		},
 	}
 }
 
-/* =====================================================================================
-   HTTP CLIENT
-===================================================================================== */
-
-var httpClient = &http.Client{
-	Timeout: 15 * time.Minute, // Very generous - actual timeout controlled by context
-	Transport: &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   10 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ResponseHeaderTimeout: 30 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	},
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
 
 /* =====================================================================================
@@ -105,6 +158,7 @@ type PRAgentRequest struct {
 	Mode                string     `json:"mode"` // describe | review
 	PR                  PRMetadata `json:"pr"`
 	DescriptionMarkdown string     `json:"description_markdown,omitempty"`
+	Cache               string     `json:"cache,omitempty"` // "" | "bypass" | "refresh"
 }
 
 type PRAgentDescribeOut struct {
@@ -115,39 +169,10 @@ type PRAgentReviewOut struct {
 	ReviewMarkdown string `json:"review_markdown"`
 }
 
-/* =====================================================================================
-   OPENAI MODELS
-===================================================================================== */
-
-type openAIChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type openAIChatRequest struct {
-	Model       string              `json:"model"`
-	Temperature float32             `json:"temperature"`
-	MaxTokens   int                 `json:"max_tokens"`
-	Messages    []openAIChatMessage `json:"messages"`
-}
-
-type openAIChatResponse struct {
-	Choices []struct {
-		Message openAIChatMessage `json:"message"`
-	} `json:"choices"`
-}
-
 /* =====================================================================================
    HELPER FUNCTIONS
 ===================================================================================== */
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 func estimateTokens(text string) int {
 	// Rough estimate: 4 characters per token
 	return len(text) / 4
@@ -176,152 +201,190 @@ func calculateDynamicTimeout(userPrompt string, baseTimeout int) time.Duration {
    LLM CALL
 ===================================================================================== */
 
-func callLLM(ctx context.Context, cfg Config, mode string, sys string, user string) (string, error) {
+// callLLM runs a non-streaming completion against the configured backend
+// (see LLM_BACKEND), logging the same debug trail regardless of provider.
+func callLLM(ctx context.Context, cfg Config, bk backend.Backend, mode string, sys string, user string) (string, error) {
+	llmInFlight.WithLabelValues(cfg.Model).Inc()
+	defer llmInFlight.WithLabelValues(cfg.Model).Dec()
 
 	start := time.Now()
 	defer func() {
 		duration := time.Since(start)
-		log.Printf("⏱️ OpenAI call completed in: %.2f seconds", duration.Seconds())
+		log.Printf("⏱️ %s backend call completed in: %.2f seconds", cfg.Backend.Name, duration.Seconds())
 	}()
 
 	log.Printf("🧪================ LLM CALL DEBUG =================")
 	log.Printf("🧪 Mode: %s", mode)
+	log.Printf("🧪 Backend: %s", backendName(cfg))
 	log.Printf("🧪 Model: %s", cfg.Model)
 	log.Printf("🧪 Max Tokens: %d", cfg.MaxTokens)
-
-	// -----------------------------------------------------
-	// VALIDATE MODEL
-	// -----------------------------------------------------
-	validModels := map[string]bool{
-		"gpt-4o":       true,
-		"gpt-4o-mini":  true,
-		"gpt-4.1":      true,
-		"gpt-4.1-mini": true,
-	}
-
-	if !validModels[cfg.Model] {
-		log.Printf("❌ INVALID MODEL NAME: %s", cfg.Model)
-		log.Printf("✔️ Allowed: gpt-4o, gpt-4o-mini, gpt-4.1, gpt-4.1-mini")
-		return "", fmt.Errorf("invalid model: %s", cfg.Model)
-	}
-	log.Printf("✔️ Model validated")
-
-	// -----------------------------------------------------
-	// CHECK OPENAI KEY
-	// -----------------------------------------------------
-	if cfg.OpenAIKey == "" {
-		log.Printf("❌ Missing OPENAI_API_KEY")
-		return "", fmt.Errorf("missing OPENAI_API_KEY")
-	}
-	log.Printf("✔️ OPENAI_API_KEY is present")
-
-	// -----------------------------------------------------
-	// DNS CHECK
-	// -----------------------------------------------------
-	log.Printf("🌐 DNS: resolving api.openai.com ...")
-	addrs, dnsErr := net.LookupHost("api.openai.com")
-	if dnsErr != nil {
-		log.Printf("❌ DNS failed: %v", dnsErr)
-		return "", dnsErr
-	}
-	log.Printf("✔️ DNS OK → %v", addrs)
-
-	// -----------------------------------------------------
-	// TCP CHECK
-	// -----------------------------------------------------
-	log.Printf("🌐 TCP: connecting to api.openai.com:443 ...")
-	conn, tcpErr := net.DialTimeout("tcp", "api.openai.com:443", 3*time.Second)
-	if tcpErr != nil {
-		log.Printf("❌ TCP failed: %v", tcpErr)
-		return "", tcpErr
-	}
-	conn.Close()
-	log.Printf("✔️ TCP connectivity OK")
-
-	// -----------------------------------------------------
-	// BUILD REQUEST
-	// -----------------------------------------------------
 	log.Printf("🧪 Sys prompt size: %d chars (~%d tokens)", len(sys), estimateTokens(sys))
 	log.Printf("🧪 User prompt size: %d chars (~%d tokens)", len(user), estimateTokens(user))
 
-	reqObj := openAIChatRequest{
+	resp, err := bk.Generate(ctx, backend.Request{
 		Model:       cfg.Model,
 		Temperature: 0.3,
 		MaxTokens:   cfg.MaxTokens,
-		Messages: []openAIChatMessage{
-			{Role: "system", Content: sys},
-			{Role: "user", Content: user},
-		},
+		System:      sys,
+		User:        user,
+	})
+	if err != nil {
+		llmErrorsTotal.WithLabelValues(cfg.Model, classifyLLMError(err)).Inc()
+		log.Printf("❌ backend call failed: %v", err)
+		return "", err
 	}
 
-	body, _ := json.Marshal(reqObj)
+	if resp.Usage.PromptTokens > 0 {
+		llmTokensTotal.WithLabelValues("prompt", cfg.Model).Add(float64(resp.Usage.PromptTokens))
+	}
+	if resp.Usage.CompletionTokens > 0 {
+		llmTokensTotal.WithLabelValues("completion", cfg.Model).Add(float64(resp.Usage.CompletionTokens))
+	}
 
-	log.Printf("📤 OpenAI Request JSON (first 400 chars):\n%s",
-		string(body[:min(400, len(body))]))
+	log.Printf("📥 Response: %d chars (~%d tokens)", len(resp.Text), estimateTokens(resp.Text))
+	log.Printf("🧪============== END LLM CALL DEBUG ==============")
 
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		"https://api.openai.com/v1/chat/completions",
-		strings.NewReader(string(body)),
-	)
-	if err != nil {
-		log.Printf("❌ Failed creating request: %v", err)
-		return "", err
-	}
+	return resp.Text, nil
+}
 
-	req.Header.Set("Authorization", "Bearer "+cfg.OpenAIKey)
-	req.Header.Set("Content-Type", "application/json")
+// cacheDirective values for PRAgentRequest.Cache.
+const (
+	cacheBypass  = "bypass"  // skip both the cache read and the write
+	cacheRefresh = "refresh" // skip the read, but still write the fresh result
+)
 
-	// -----------------------------------------------------
-	// SEND REQUEST
-	// -----------------------------------------------------
-	log.Printf("🚀 Calling OpenAI model=%s mode=%s ...", cfg.Model, mode)
+// cachedCallLLM looks up a response cached under sha256(mode|model|sys|user)
+// before falling back to callLLM, so re-running describe/review against an
+// unchanged diff doesn't re-issue an identical, billable LLM call.
+// directive (PRAgentRequest.Cache) lets a caller force regeneration.
+func cachedCallLLM(ctx context.Context, cfg Config, rc cache.Cache, bk backend.Backend, pr PRMetadata, directive, mode, sys, user string) (string, error) {
+	key := cache.Key(mode, cfg.Model, sys, user)
+
+	if directive != cacheBypass && directive != cacheRefresh {
+		if entry, hit, err := rc.Get(ctx, key); err != nil {
+			log.Printf("⚠️ cache: lookup failed, falling back to LLM: %v", err)
+		} else if hit {
+			cacheHitsTotal.WithLabelValues(mode).Inc()
+			log.Printf("✅ cache hit for %s (head_sha=%s)", mode, pr.HeadSHA)
+			return entry.Markdown, nil
+		}
+	}
 
-	resp, err := httpClient.Do(req)
+	out, err := callLLM(ctx, cfg, bk, mode, sys, user)
 	if err != nil {
-		log.Printf("❌ Network error: %v", err)
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	log.Printf("🌐 OpenAI returned status: %d", resp.StatusCode)
-
-	if resp.StatusCode >= 300 {
-		errBody, _ := io.ReadAll(resp.Body)
-		log.Printf("❌ OpenAI Error Body:\n%s", string(errBody))
-		return "", fmt.Errorf("openai error: %d", resp.StatusCode)
+	if directive != cacheBypass {
+		entry := cache.Entry{
+			Markdown:  out,
+			RepoOwner: pr.RepoOwner,
+			RepoName:  pr.RepoName,
+			PRNumber:  pr.PRNumber,
+			HeadSHA:   pr.HeadSHA,
+		}
+		if err := rc.Set(ctx, key, entry, cfg.CacheTTL); err != nil {
+			log.Printf("⚠️ cache: failed to store entry: %v", err)
+		}
 	}
 
-	// -----------------------------------------------------
-	// PARSE RESPONSE
-	// -----------------------------------------------------
-	var parsed openAIChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		log.Printf("❌ Decode error: %v", err)
-		return "", err
+	return out, nil
+}
+
+/* =====================================================================================
+   LLM CALL (STREAMING)
+===================================================================================== */
+
+// wantsSSE reports whether the caller asked for an SSE-streamed response.
+func wantsSSE(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// writeSSEToken writes one token as an SSE "message" event, JSON-encoded so
+// multi-line completions can't break the event framing.
+func writeSSEToken(w io.Writer, token string) {
+	payload, _ := json.Marshal(map[string]string{"token": token})
+	fmt.Fprintf(w, "data: %s\n\n", payload)
+}
+
+// streamLLMResponse drives an SSE response for a /post request: it streams
+// each token as it arrives from the configured backend, flushing after
+// every chunk so the orchestrator sees partial progress, then emits a
+// final "done" event carrying the assembled
+// PRAgentDescribeOut/PRAgentReviewOut JSON.
+func streamLLMResponse(w http.ResponseWriter, ctx context.Context, cfg Config, bk backend.Backend, mode, sys, user string, buildOut func(full string) interface{}) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", 500)
+		return
 	}
 
-	if len(parsed.Choices) == 0 {
-		log.Printf("❌ Empty choices from OpenAI")
-		return "", fmt.Errorf("empty OpenAI response")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	log.Printf("📡 streaming %s response via SSE (backend=%s)", mode, backendName(cfg))
+
+	resp, err := bk.GenerateStream(ctx, backend.Request{
+		Model:       cfg.Model,
+		Temperature: 0.3,
+		MaxTokens:   cfg.MaxTokens,
+		System:      sys,
+		User:        user,
+	}, func(token string) {
+		writeSSEToken(w, token)
+		flusher.Flush()
+	})
+	if err != nil {
+		log.Printf("❌ %s stream failed: %v", mode, err)
+		fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+		flusher.Flush()
+		return
 	}
 
-	out := parsed.Choices[0].Message.Content
+	final, _ := json.Marshal(buildOut(resp.Text))
+	fmt.Fprintf(w, "event: done\ndata: %s\n\n", final)
+	flusher.Flush()
+	log.Printf("✅ %s SSE stream completed", mode)
+}
 
-	log.Printf("📥 OpenAI Response: %d chars (~%d tokens)", len(out), estimateTokens(out))
-	log.Printf("📥 Response Preview:\n%s", out[:min(300, len(out))])
+// writeSSEFinal responds to an SSE request whose output isn't produced
+// token-by-token (the map-reduce review's reduce step runs as one or more
+// whole-chunk backend calls, not a single streamed completion) with a
+// single "done" event carrying the already-assembled out, rather than
+// silently falling back to a buffered JSON body that violates the
+// Accept: text/event-stream contract the caller asked for.
+func writeSSEFinal(w http.ResponseWriter, mode string, out interface{}) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", 500)
+		return
+	}
 
-	log.Printf("🧪============== END LLM CALL DEBUG ==============")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
 
-	return out, nil
+	final, _ := json.Marshal(out)
+	fmt.Fprintf(w, "event: done\ndata: %s\n\n", final)
+	flusher.Flush()
+	log.Printf("✅ %s SSE stream completed (non-incremental map-reduce output)", mode)
+}
+
+func backendName(cfg Config) string {
+	if cfg.Backend.Name == "" {
+		return "openai"
+	}
+	return cfg.Backend.Name
 }
 
 /* =====================================================================================
    HTTP HANDLER
 ===================================================================================== */
 
-func prAgentHandler(cfg Config) http.HandlerFunc {
+func prAgentHandler(cfg Config, bk backend.Backend, rc cache.Cache) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		raw, _ := io.ReadAll(r.Body)
@@ -362,7 +425,14 @@ LocalPath: %s
 
 			log.Printf("⏱️ Starting describe with %v timeout", timeout)
 
-			out, err := callLLM(ctx, cfg, "describe", sys, userPrompt)
+			if wantsSSE(r) {
+				streamLLMResponse(w, ctx, cfg, bk, "describe", sys, userPrompt, func(full string) interface{} {
+					return PRAgentDescribeOut{DescriptionMarkdown: full}
+				})
+				return
+			}
+
+			out, err := cachedCallLLM(ctx, cfg, rc, bk, req.PR, req.Cache, "describe", sys, userPrompt)
 			if err != nil {
 				log.Printf("❌ describe failed: %v", err)
 				http.Error(w, err.Error(), 500)
@@ -399,7 +469,35 @@ Local path: %s
 
 			log.Printf("⏱️ Starting review with %v timeout", timeout)
 
-			out, err := callLLM(ctx, cfg, "review", sys, userPrompt)
+			if markdown, ok, mrErr := runMapReduceReview(ctx, cfg, bk, req.PR, req.DescriptionMarkdown); ok {
+				if mrErr != nil {
+					log.Printf("❌ review failed: %v", mrErr)
+					http.Error(w, mrErr.Error(), 500)
+					return
+				}
+				log.Printf("✅ diff-aware map-reduce review completed successfully")
+				out := PRAgentReviewOut{ReviewMarkdown: markdown}
+				if wantsSSE(r) {
+					// The reduce step already ran as whole-chunk backend
+					// calls, not a single streamed completion, so there's
+					// no token stream to relay -- send the assembled
+					// result as one SSE "done" event instead of silently
+					// falling back to a buffered JSON body.
+					writeSSEFinal(w, "review", out)
+					return
+				}
+				json.NewEncoder(w).Encode(out)
+				return
+			}
+
+			if wantsSSE(r) {
+				streamLLMResponse(w, ctx, cfg, bk, "review", sys, userPrompt, func(full string) interface{} {
+					return PRAgentReviewOut{ReviewMarkdown: full}
+				})
+				return
+			}
+
+			out, err := cachedCallLLM(ctx, cfg, rc, bk, req.PR, req.Cache, "review", sys, userPrompt)
 			if err != nil {
 				log.Printf("❌ review failed: %v", err)
 				http.Error(w, err.Error(), 500)
@@ -410,6 +508,10 @@ Local path: %s
 			json.NewEncoder(w).Encode(PRAgentReviewOut{ReviewMarkdown: out})
 			return
 
+		case "security":
+			handleSecurityMode(w, r, cfg, bk, req)
+			return
+
 		default:
 			http.Error(w, "unknown mode", 400)
 		}
@@ -432,12 +534,30 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 func main() {
 	cfg := loadConfig()
 
-	http.HandleFunc("/post", prAgentHandler(cfg))
+	bk, err := backend.New(cfg.Backend)
+	if err != nil {
+		log.Fatalf("❌ failed to construct LLM backend: %v", err)
+	}
+
+	rc, err := cache.New(cache.Config{MaxEntries: cfg.CacheMaxEntries, RedisURL: cfg.CacheRedisURL})
+	if err != nil {
+		log.Fatalf("❌ failed to construct response cache: %v", err)
+	}
+
+	http.HandleFunc("/post", metricsMiddleware(prAgentHandler(cfg, bk, rc)))
 	http.HandleFunc("/health", healthHandler)
+	http.Handle("/metrics", metricsAuthMiddleware(cfg, promhttp.Handler()))
+	http.HandleFunc("/cache/invalidate", cacheInvalidateHandler(rc))
 
 	log.Printf("🚀 PR-Review Agent running on %s", cfg.ListenAddr)
+	log.Printf("🔌 LLM backend: %s", backendName(cfg))
 	log.Printf("📋 Model: %s", cfg.Model)
 	log.Printf("⏱️ Base Timeout: %d seconds", cfg.Timeout)
 	log.Printf("🎯 Max Tokens: %d", cfg.MaxTokens)
+	cacheKind := "in-memory"
+	if cfg.CacheRedisURL != "" {
+		cacheKind = "redis"
+	}
+	log.Printf("🗄️ Response cache: %s (ttl=%s)", cacheKind, cfg.CacheTTL)
 	log.Fatal(http.ListenAndServe(cfg.ListenAddr, nil))
 }
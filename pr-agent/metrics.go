@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+/* =====================================================================================
+   METRICS
+
+   Replaces the ad-hoc log.Printf debug trail with numbers ops can alert
+   on. requestsTotal/requestDuration cover the /post handler as a whole;
+   llmTokensTotal/llmErrorsTotal/llmInFlight cover the backend.Generate
+   call made from callLLM.
+===================================================================================== */
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pragent_requests_total",
+		Help: "Total /post requests handled, by mode and response status.",
+	}, []string{"mode", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "pragent_request_duration_seconds",
+		Help: "Latency of /post requests, by mode.",
+	}, []string{"mode"})
+
+	llmTokensTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pragent_llm_tokens_total",
+		Help: "Tokens reported by the LLM backend, by direction (prompt|completion) and model.",
+	}, []string{"direction", "model"})
+
+	llmErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pragent_llm_errors_total",
+		Help: "LLM backend call failures, by model and failure kind (dns|tcp|http|decode|other).",
+	}, []string{"model", "kind"})
+
+	llmInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pragent_llm_in_flight",
+		Help: "LLM backend calls currently in flight, by model.",
+	}, []string{"model"})
+
+	cacheHitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pragent_cache_hits_total",
+		Help: "Requests served from the response cache without an LLM call, by mode.",
+	}, []string{"mode"})
+)
+
+// statusRecorder wraps an http.ResponseWriter to capture the status code
+// written, defaulting to 200 since handlers are allowed to never call
+// WriteHeader explicitly (e.g. a bare json.NewEncoder(w).Encode(...)).
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush forwards to the underlying ResponseWriter so streamLLMResponse's
+// SSE path keeps working once it's wrapped by metricsMiddleware.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// metricsMiddleware instruments next with pragent_requests_total and
+// pragent_request_duration_seconds, labeled by mode and final status.
+func metricsMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		mode := requestMode(r)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next(rec, r)
+
+		requestsTotal.WithLabelValues(mode, strconv.Itoa(rec.status)).Inc()
+		requestDuration.WithLabelValues(mode).Observe(time.Since(start).Seconds())
+	}
+}
+
+// requestMode peeks at the "mode" field of a /post body without consuming
+// it, so prAgentHandler still sees and decodes the full body itself.
+func requestMode(r *http.Request) string {
+	if r.Body == nil {
+		return "unknown"
+	}
+	raw, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+	if err != nil {
+		return "unknown"
+	}
+
+	var peek struct {
+		Mode string `json:"mode"`
+	}
+	if json.Unmarshal(raw, &peek) != nil || peek.Mode == "" {
+		return "unknown"
+	}
+	return peek.Mode
+}
+
+// metricsAuthMiddleware gates access to /metrics behind a shared secret
+// (cfg.MetricsAuthToken, from METRICS_AUTH_TOKEN) compared in constant
+// time. An unset token leaves /metrics open, matching today's
+// unauthenticated default.
+func metricsAuthMiddleware(cfg Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.MetricsAuthToken == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !hmac.Equal([]byte(got), []byte(cfg.MetricsAuthToken)) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// classifyLLMError buckets a backend error for the pragent_llm_errors_total
+// label. Network-level failures are distinguished via their concrete
+// error types; HTTP and decode failures from the backend packages are
+// plain fmt.Errorf strings, so those fall back to a substring check.
+func classifyLLMError(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return "dns"
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return "tcp"
+	}
+	var syntaxErr *json.SyntaxError
+	var unmarshalErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &unmarshalErr) {
+		return "decode"
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "error: "):
+		// e.g. "openai error: 429: rate limited", "azure openai error: ..."
+		return "http"
+	case strings.Contains(msg, "empty") || strings.Contains(msg, "response"):
+		return "decode"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"pr-agent/backend"
+	"pr-agent/diff"
+)
+
+/* =====================================================================================
+   DIFF-AWARE MAP-REDUCE REVIEW
+
+   Large PRs blow past the model's context window if the whole diff is
+   inlined into one prompt. runMapReduceReview collects the PR's unified
+   diff, splits it into token-budgeted chunks, reviews each chunk
+   concurrently (bounded by cfg.MaxParallelLLM), and reduces the chunk-level
+   comments into one ReviewMarkdown with stable, path-ordered sections.
+===================================================================================== */
+
+// runMapReduceReview is the diff-aware review path. ok reports whether the
+// diff could be collected and chunked at all; when ok is false the caller
+// should fall back to the single-call review over the description alone
+// (e.g. no git metadata, or the PR has no hunks to review).
+func runMapReduceReview(ctx context.Context, cfg Config, bk backend.Backend, meta PRMetadata, description string) (markdown string, ok bool, err error) {
+	diffText, err := diff.Collect(ctx, meta.LocalPath, meta.SourceBranch, meta.TargetBranch)
+	if err != nil {
+		log.Printf("⚠️ map-reduce review: could not collect diff, falling back to single-call review: %v", err)
+		return "", false, nil
+	}
+
+	hunks := diff.SplitHunks(diffText)
+	if len(hunks) == 0 {
+		log.Printf("⚠️ map-reduce review: diff had no reviewable hunks, falling back to single-call review")
+		return "", false, nil
+	}
+
+	chunks := diff.ChunkHunks(hunks, cfg.ReviewChunkTokenBudget, estimateTokens)
+	log.Printf("🗺️ map-reduce review: %d hunks over %d files split into %d chunks (budget=%d tokens, parallel=%d)",
+		len(hunks), countFiles(hunks), len(chunks), cfg.ReviewChunkTokenBudget, maxParallel(cfg))
+
+	chunkFindings, err := mapChunks(ctx, cfg, bk, description, chunks)
+	if err != nil {
+		return "", true, err
+	}
+
+	markdown, err = reduceFindings(ctx, cfg, bk, description, chunkFindings)
+	if err != nil {
+		return "", true, err
+	}
+	return markdown, true, nil
+}
+
+func countFiles(hunks []diff.Hunk) int {
+	seen := map[string]bool{}
+	for _, h := range hunks {
+		seen[h.Path] = true
+	}
+	return len(seen)
+}
+
+func maxParallel(cfg Config) int {
+	if cfg.MaxParallelLLM > 0 {
+		return cfg.MaxParallelLLM
+	}
+	return 3
+}
+
+// mapChunks reviews each chunk concurrently through a worker pool bounded
+// by cfg.MaxParallelLLM, returning findings in chunk (diff) order rather
+// than completion order so the reduce step sees a stable input.
+func mapChunks(ctx context.Context, cfg Config, bk backend.Backend, description string, chunks []diff.Chunk) ([]string, error) {
+	results := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+
+	sem := make(chan struct{}, maxParallel(cfg))
+	var wg sync.WaitGroup
+
+	for i, chunk := range chunks {
+		i, chunk := i, chunk
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = reviewChunk(ctx, cfg, bk, description, i+1, len(chunks), chunk)
+		}()
+	}
+	wg.Wait()
+
+	for _, chunkErr := range errs {
+		if chunkErr != nil {
+			return nil, chunkErr
+		}
+	}
+	return results, nil
+}
+
+// reviewChunk asks the LLM for localized, path:line-anchored comments on a
+// single diff chunk.
+func reviewChunk(ctx context.Context, cfg Config, bk backend.Backend, description string, index, total int, chunk diff.Chunk) (string, error) {
+	sys := "You are a Staff Engineer reviewing one slice of a larger pull request diff."
+	user := fmt.Sprintf(`
+PR description:
+%s
+
+This is chunk %d of %d. Review only the diff slice below. Anchor every
+comment to its "path:line" so it can be posted as an inline comment later.
+
+%s
+`, description, index, total, chunk.Render())
+
+	resp, err := bk.Generate(ctx, backend.Request{
+		Model:       cfg.Model,
+		Temperature: 0.3,
+		MaxTokens:   cfg.MaxTokens,
+		System:      sys,
+		User:        user,
+	})
+	if err != nil {
+		return "", fmt.Errorf("review chunk %d/%d: %w", index, total, err)
+	}
+	return resp.Text, nil
+}
+
+// reduceFindings consolidates every chunk's localized comments into one
+// ReviewMarkdown, deduplicating overlapping findings and ordering sections
+// by file path for a stable result.
+func reduceFindings(ctx context.Context, cfg Config, bk backend.Backend, description string, chunkFindings []string) (string, error) {
+	var combined strings.Builder
+	for i, findings := range chunkFindings {
+		fmt.Fprintf(&combined, "### Chunk %d findings\n%s\n\n", i+1, findings)
+	}
+
+	sys := "You are a Staff Engineer consolidating chunk-level review notes into one PR review."
+	user := fmt.Sprintf(`
+PR description:
+%s
+
+Per-chunk findings, in diff order:
+%s
+
+Merge these into a single review. Drop duplicate comments about the same
+path:line, keep every remaining path:line anchor, and order sections
+alphabetically by file path.
+`, description, combined.String())
+
+	resp, err := bk.Generate(ctx, backend.Request{
+		Model:       cfg.Model,
+		Temperature: 0.3,
+		MaxTokens:   cfg.MaxTokens,
+		System:      sys,
+		User:        user,
+	})
+	if err != nil {
+		return "", fmt.Errorf("reduce findings: %w", err)
+	}
+	return resp.Text, nil
+}
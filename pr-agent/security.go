@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"pr-agent/backend"
+)
+
+/* =====================================================================================
+   SECURITY MODE
+
+   Runs Semgrep directly against the checked-out PR and feeds the findings,
+   together with the describe-mode description, back into the LLM to
+   produce a prioritized, deduplicated write-up. Mirrors the shape of
+   internal.SemgrepOut/SemgrepSeveritySummary from the orchestrator so the
+   two sides of the contract stay interchangeable.
+===================================================================================== */
+
+// SemgrepSeveritySummary tallies findings by severity, matching the
+// orchestrator's internal.SemgrepSeveritySummary.
+type SemgrepSeveritySummary struct {
+	Blocker  int `json:"blocker"`
+	Critical int `json:"critical"`
+	Major    int `json:"major"`
+	Minor    int `json:"minor"`
+	Info     int `json:"info"`
+}
+
+// Finding is a single structured Semgrep result, matching the
+// orchestrator's internal.Finding.
+type Finding struct {
+	RuleID   string `json:"rule_id"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// SemgrepOut is returned by mode=security, matching the orchestrator's
+// internal.SemgrepOut.
+type SemgrepOut struct {
+	FindingsMarkdown string                 `json:"findings_markdown"`
+	Severity         SemgrepSeveritySummary `json:"severity"`
+	Findings         []Finding              `json:"findings,omitempty"`
+}
+
+// severityRank orders severities from least to most urgent so min_severity
+// can be compared against whatever Semgrep reported.
+var severityRank = map[string]int{
+	"info":     0,
+	"minor":    1,
+	"major":    2,
+	"critical": 3,
+	"blocker":  4,
+}
+
+type semgrepCLIResult struct {
+	CheckID string `json:"check_id"`
+	Path    string `json:"path"`
+	Start   struct {
+		Line int `json:"line"`
+	} `json:"start"`
+	Extra struct {
+		Severity string `json:"severity"`
+		Message  string `json:"message"`
+	} `json:"extra"`
+}
+
+type semgrepCLIOutput struct {
+	Results []semgrepCLIResult `json:"results"`
+	Errors  []interface{}      `json:"errors"`
+}
+
+// handleSecurityMode walks the PR's checked-out files, runs `semgrep
+// --json` over them, tallies the findings and asks the LLM to turn them
+// into a prioritized write-up. If min_severity is set on the query string
+// and any finding meets or exceeds it, the response is sent with HTTP 422
+// so the orchestrator can fail the PR check.
+func handleSecurityMode(w http.ResponseWriter, r *http.Request, cfg Config, bk backend.Backend, req PRAgentRequest) {
+	ctx := r.Context()
+
+	if req.PR.LocalPath == "" {
+		http.Error(w, "pr.local_path is required for mode=security", 400)
+		return
+	}
+
+	files, err := enumerateSourceFiles(req.PR.LocalPath)
+	if err != nil {
+		log.Printf("❌ security: failed to enumerate files: %v", err)
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if len(files) == 0 {
+		log.Printf("⚠️ security: no source files found under %s", req.PR.LocalPath)
+		json.NewEncoder(w).Encode(SemgrepOut{FindingsMarkdown: "No source files found to scan. ✅"})
+		return
+	}
+
+	log.Printf("🔍 security: running semgrep --config=%s over %d files", cfg.SemgrepConfig, len(files))
+
+	output, err := runSemgrep(ctx, req.PR.LocalPath, cfg.SemgrepConfig)
+	if err != nil {
+		log.Printf("❌ security: semgrep failed: %v", err)
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	severity := SemgrepSeveritySummary{}
+	findings := make([]Finding, 0, len(output.Results))
+	for _, res := range output.Results {
+		sev := strings.ToLower(res.Extra.Severity)
+		switch sev {
+		case "blocker":
+			severity.Blocker++
+		case "error", "critical":
+			severity.Critical++
+			sev = "critical"
+		case "warning", "major":
+			severity.Major++
+			sev = "major"
+		case "note", "minor":
+			severity.Minor++
+			sev = "minor"
+		default:
+			severity.Info++
+			sev = "info"
+		}
+		findings = append(findings, Finding{
+			RuleID:   res.CheckID,
+			Path:     res.Path,
+			Line:     res.Start.Line,
+			Severity: sev,
+			Message:  res.Extra.Message,
+		})
+	}
+
+	log.Printf("📊 security: %d findings (blocker=%d critical=%d major=%d minor=%d info=%d)",
+		len(findings), severity.Blocker, severity.Critical, severity.Major, severity.Minor, severity.Info)
+
+	markdown, err := summarizeFindings(ctx, cfg, bk, req.DescriptionMarkdown, findings)
+	if err != nil {
+		log.Printf("❌ security: failed to summarize findings: %v", err)
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	out := SemgrepOut{
+		FindingsMarkdown: markdown,
+		Severity:         severity,
+		Findings:         findings,
+	}
+
+	status := http.StatusOK
+	if threshold := r.URL.Query().Get("min_severity"); threshold != "" {
+		if exceedsThreshold(findings, threshold) {
+			log.Printf("🚫 security: a finding meets or exceeds min_severity=%s, failing the check", threshold)
+			status = http.StatusUnprocessableEntity
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(out)
+}
+
+// exceedsThreshold reports whether any finding's severity is at or above
+// the requested min_severity (info|minor|major|critical|blocker).
+func exceedsThreshold(findings []Finding, threshold string) bool {
+	thresholdRank, ok := severityRank[strings.ToLower(threshold)]
+	if !ok {
+		return false
+	}
+	for _, f := range findings {
+		if severityRank[f.Severity] >= thresholdRank {
+			return true
+		}
+	}
+	return false
+}
+
+// enumerateSourceFiles walks localPath and returns every source file, so
+// callers can short-circuit before invoking semgrep on an empty checkout.
+func enumerateSourceFiles(localPath string) ([]string, error) {
+	supportedExts := map[string]bool{
+		".go": true, ".py": true, ".js": true, ".ts": true, ".jsx": true,
+		".tsx": true, ".java": true, ".rb": true, ".php": true, ".cs": true,
+		".c": true, ".cpp": true, ".cc": true, ".h": true, ".hpp": true,
+	}
+
+	var files []string
+	err := filepath.WalkDir(localPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", "node_modules", "vendor", "__pycache__", ".venv", "venv", "dist", "build":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if supportedExts[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", localPath, err)
+	}
+	return files, nil
+}
+
+// runSemgrep shells out to the semgrep CLI and parses its JSON output.
+func runSemgrep(ctx context.Context, localPath, semgrepConfig string) (semgrepCLIOutput, error) {
+	cmd := exec.CommandContext(ctx, "semgrep", "--json", "--config="+semgrepConfig, localPath)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// Semgrep exits non-zero when it finds blocking issues, so only treat
+	// it as a real failure if we got no parseable JSON back.
+	runErr := cmd.Run()
+
+	var output semgrepCLIOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		if runErr != nil {
+			return semgrepCLIOutput{}, fmt.Errorf("run semgrep: %w (stderr: %s)", runErr, stderr.String())
+		}
+		return semgrepCLIOutput{}, fmt.Errorf("parse semgrep output: %w", err)
+	}
+
+	return output, nil
+}
+
+// summarizeFindings asks the configured LLM backend to turn the raw
+// Semgrep findings into a prioritized, deduplicated markdown write-up,
+// using the PR description for context.
+func summarizeFindings(ctx context.Context, cfg Config, bk backend.Backend, description string, findings []Finding) (string, error) {
+	if len(findings) == 0 {
+		return "No security issues found by Semgrep. ✅", nil
+	}
+
+	var raw strings.Builder
+	for _, f := range findings {
+		fmt.Fprintf(&raw, "- [%s] %s:%d (%s) - %s\n", f.Severity, f.Path, f.Line, f.RuleID, f.Message)
+	}
+
+	sys := "You are a Staff Engineer triaging static analysis results for a pull request."
+	user := fmt.Sprintf(`
+PR description:
+%s
+
+Raw Semgrep findings:
+%s
+
+Deduplicate findings that point at the same underlying issue, prioritize by
+severity and exploitability, and write the result as a markdown report with
+one section per severity level.
+`, description, raw.String())
+
+	resp, err := bk.Generate(ctx, backend.Request{
+		Model:       cfg.Model,
+		Temperature: 0.3,
+		MaxTokens:   cfg.MaxTokens,
+		System:      sys,
+		User:        user,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FindingCache stores scan results at two granularities so performScan can
+// skip Semgrep MCP calls entirely when it's seen the work before:
+//
+//   - GetFile/SetFile cache one file's findings under
+//     sha256(content)+scannerProfile, so an unchanged file re-submitted in a
+//     different ScanRequest (common across PRs touching a large monorepo)
+//     is served without scanning.
+//   - GetScan/SetScan cache a whole ScanResponse under a hash of the
+//     request's files plus its scanner profile, so a byte-identical
+//     ScanRequest (e.g. a retried webhook delivery) returns instantly.
+//
+// The memory-backed implementation is the default; a Redis-backed one can
+// be selected via CACHE_REDIS_URL so findings survive a pod restart.
+type FindingCache interface {
+	GetFile(ctx context.Context, key string) ([]Finding, bool, error)
+	SetFile(ctx context.Context, key string, findings []Finding) error
+	GetScan(ctx context.Context, key string) (ScanResponse, bool, error)
+	SetScan(ctx context.Context, key string, resp ScanResponse) error
+	Stats() CacheStats
+	Purge()
+}
+
+// CacheStats is the body of GET /cache/stats.
+type CacheStats struct {
+	Backend     string `json:"backend"` // "memory" | "redis"
+	FileEntries int    `json:"file_entries"`
+	ScanEntries int    `json:"scan_entries"`
+	FileHits    int64  `json:"file_hits"`
+	FileMisses  int64  `json:"file_misses"`
+	ScanHits    int64  `json:"scan_hits"`
+	ScanMisses  int64  `json:"scan_misses"`
+}
+
+// cacheConfig sizes and selects the FindingCache implementation.
+type cacheConfig struct {
+	MaxEntries int // in-memory LRU size, shared by file and scan entries; ignored when RedisURL is set
+	TTL        time.Duration
+	RedisURL   string // CACHE_REDIS_URL, e.g. "redis://cache:6379/0"
+}
+
+// newFindingCache constructs the cache selected by cfg, defaulting to an
+// in-memory LRU so deployments that don't set CACHE_REDIS_URL still skip
+// re-scanning unchanged files.
+func newFindingCache(cfg cacheConfig) (FindingCache, error) {
+	if cfg.RedisURL != "" {
+		return newFindingRedisCache(cfg.RedisURL)
+	}
+	return newFindingMemoryCache(cfg.MaxEntries, cfg.TTL), nil
+}
+
+// scannerProfile fingerprints which scanners are enabled, sorted so
+// reordering SCANNERS doesn't invalidate the cache. It's folded into every
+// cache key because the same file content can yield different findings
+// under a different scanner set.
+func scannerProfile(scanners []Scanner) string {
+	names := make([]string, len(scanners))
+	for i, sc := range scanners {
+		names[i] = sc.Name()
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// fileCacheKey is sha256(content)+profile, hex-encoded.
+func fileCacheKey(content, profile string) string {
+	h := sha256.New()
+	h.Write([]byte(content))
+	h.Write([]byte{'|'})
+	h.Write([]byte(profile))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// scanCacheKey hashes every file in the request (sorted by name so map
+// iteration order can't change the key) plus the request fields that
+// affect the response, so two requests only collide in the cache if
+// they'd have produced the same ScanResponse.
+func scanCacheKey(req ScanRequest, profile string) string {
+	names := make([]string, 0, len(req.Files))
+	for name := range req.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{'\x00'})
+		h.Write([]byte(req.Files[name]))
+		h.Write([]byte{'\x00'})
+	}
+	h.Write([]byte(profile))
+	h.Write([]byte{'|'})
+	h.Write([]byte(req.BaseSHA))
+	h.Write([]byte{'|'})
+	h.Write([]byte(strconv.FormatBool(req.DiffScan)))
+	h.Write([]byte{'|'})
+	h.Write([]byte(req.UnifiedDiff))
+	h.Write([]byte{'|'})
+	h.Write([]byte(strings.Join(req.OutputFormats, ",")))
+	return hex.EncodeToString(h.Sum(nil))
+}
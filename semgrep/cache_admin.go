@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// cacheStatsHandler reports hit/miss counts and entry sizes so an operator
+// can tell whether the cache is actually saving MCP calls.
+func (s *SemgrepServer) cacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.cache.Stats())
+}
+
+// cachePurgeHandler drops every cached file and scan entry, for use after a
+// ruleset change makes previously-cached findings stale.
+func (s *SemgrepServer) cachePurgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.cache.Purge()
+	s.log.Info().Msg("cache purged")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "purged"})
+}
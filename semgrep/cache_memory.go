@@ -0,0 +1,156 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// findingMemoryCache is a size-bounded, TTL'd LRU covering both cache
+// kinds. It's the default backend, at the cost of a cold cache on every
+// restart.
+type findingMemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	items      map[string]*list.Element
+	order      *list.List // front = most recently used
+
+	fileHits, fileMisses int64
+	scanHits, scanMisses int64
+}
+
+type findingMemoryItem struct {
+	key          string
+	kind         string // "file" or "scan"
+	fileFindings []Finding
+	scanResp     ScanResponse
+	expiresAt    time.Time
+}
+
+func newFindingMemoryCache(maxEntries int, ttl time.Duration) *findingMemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = 5000
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &findingMemoryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *findingMemoryCache) GetFile(ctx context.Context, key string) ([]Finding, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.getLocked("file:" + key)
+	if !ok {
+		c.fileMisses++
+		return nil, false, nil
+	}
+	c.fileHits++
+	return item.fileFindings, true, nil
+}
+
+func (c *findingMemoryCache) SetFile(ctx context.Context, key string, findings []Finding) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked("file:"+key, &findingMemoryItem{kind: "file", fileFindings: findings})
+	return nil
+}
+
+func (c *findingMemoryCache) GetScan(ctx context.Context, key string) (ScanResponse, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.getLocked("scan:" + key)
+	if !ok {
+		c.scanMisses++
+		return ScanResponse{}, false, nil
+	}
+	c.scanHits++
+	return item.scanResp, true, nil
+}
+
+func (c *findingMemoryCache) SetScan(ctx context.Context, key string, resp ScanResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked("scan:"+key, &findingMemoryItem{kind: "scan", scanResp: resp})
+	return nil
+}
+
+func (c *findingMemoryCache) getLocked(fullKey string) (*findingMemoryItem, bool) {
+	el, ok := c.items[fullKey]
+	if !ok {
+		return nil, false
+	}
+	item := el.Value.(*findingMemoryItem)
+	if time.Now().After(item.expiresAt) {
+		c.removeLocked(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return item, true
+}
+
+func (c *findingMemoryCache) setLocked(fullKey string, item *findingMemoryItem) {
+	item.key = fullKey
+	item.expiresAt = time.Now().Add(c.ttl)
+
+	if el, ok := c.items[fullKey]; ok {
+		c.order.Remove(el)
+	}
+	el := c.order.PushFront(item)
+	c.items[fullKey] = el
+
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.removeLocked(oldest)
+	}
+}
+
+func (c *findingMemoryCache) removeLocked(el *list.Element) {
+	item := el.Value.(*findingMemoryItem)
+	c.order.Remove(el)
+	delete(c.items, item.key)
+}
+
+func (c *findingMemoryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{
+		Backend:    "memory",
+		FileHits:   c.fileHits,
+		FileMisses: c.fileMisses,
+		ScanHits:   c.scanHits,
+		ScanMisses: c.scanMisses,
+	}
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		item := el.Value.(*findingMemoryItem)
+		if item.kind == "file" {
+			stats.FileEntries++
+		} else {
+			stats.ScanEntries++
+		}
+	}
+	return stats
+}
+
+func (c *findingMemoryCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order = list.New()
+	c.fileHits, c.fileMisses = 0, 0
+	c.scanHits, c.scanMisses = 0, 0
+}
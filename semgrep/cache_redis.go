@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// findingRedisCache stores entries as JSON so a cached finding survives a
+// pod restart. Hit/miss counters are kept in-process rather than in Redis:
+// they're exposed purely for operator visibility on /cache/stats, and
+// aren't worth a round trip (or cross-replica consistency) to maintain.
+type findingRedisCache struct {
+	client *redis.Client
+
+	fileHits, fileMisses int64
+	scanHits, scanMisses int64
+}
+
+func newFindingRedisCache(url string) (*findingRedisCache, error) {
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("parse CACHE_REDIS_URL: %w", err)
+	}
+	return &findingRedisCache{client: redis.NewClient(opt)}, nil
+}
+
+func (c *findingRedisCache) GetFile(ctx context.Context, key string) ([]Finding, bool, error) {
+	raw, err := c.client.Get(ctx, "semgrep:cache:file:"+key).Bytes()
+	if err == redis.Nil {
+		atomic.AddInt64(&c.fileMisses, 1)
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var findings []Finding
+	if err := json.Unmarshal(raw, &findings); err != nil {
+		return nil, false, err
+	}
+	atomic.AddInt64(&c.fileHits, 1)
+	return findings, true, nil
+}
+
+func (c *findingRedisCache) SetFile(ctx context.Context, key string, findings []Finding) error {
+	raw, err := json.Marshal(findings)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, "semgrep:cache:file:"+key, raw, 0).Err()
+}
+
+func (c *findingRedisCache) GetScan(ctx context.Context, key string) (ScanResponse, bool, error) {
+	raw, err := c.client.Get(ctx, "semgrep:cache:scan:"+key).Bytes()
+	if err == redis.Nil {
+		atomic.AddInt64(&c.scanMisses, 1)
+		return ScanResponse{}, false, nil
+	}
+	if err != nil {
+		return ScanResponse{}, false, err
+	}
+
+	var resp ScanResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return ScanResponse{}, false, err
+	}
+	atomic.AddInt64(&c.scanHits, 1)
+	return resp, true, nil
+}
+
+func (c *findingRedisCache) SetScan(ctx context.Context, key string, resp ScanResponse) error {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, "semgrep:cache:scan:"+key, raw, 0).Err()
+}
+
+// Stats reports the number of keys under each cache prefix (found with
+// KEYS) as the entry counts, so unrelated keys in a shared Redis DB are
+// not counted.
+func (c *findingRedisCache) Stats() CacheStats {
+	ctx := context.Background()
+	fileCount, _ := c.client.Keys(ctx, "semgrep:cache:file:*").Result()
+	scanCount, _ := c.client.Keys(ctx, "semgrep:cache:scan:*").Result()
+	return CacheStats{
+		Backend:     "redis",
+		FileEntries: len(fileCount),
+		ScanEntries: len(scanCount),
+		FileHits:    atomic.LoadInt64(&c.fileHits),
+		FileMisses:  atomic.LoadInt64(&c.fileMisses),
+		ScanHits:    atomic.LoadInt64(&c.scanHits),
+		ScanMisses:  atomic.LoadInt64(&c.scanMisses),
+	}
+}
+
+// Purge deletes every key under this service's cache prefixes. It
+// deliberately doesn't FLUSHDB, so other keys in a shared Redis DB are
+// left alone.
+func (c *findingRedisCache) Purge() {
+	ctx := context.Background()
+	for _, pattern := range []string{"semgrep:cache:file:*", "semgrep:cache:scan:*"} {
+		keys, err := c.client.Keys(ctx, pattern).Result()
+		if err != nil || len(keys) == 0 {
+			continue
+		}
+		c.client.Del(ctx, keys...)
+	}
+	atomic.StoreInt64(&c.fileHits, 0)
+	atomic.StoreInt64(&c.fileMisses, 0)
+	atomic.StoreInt64(&c.scanHits, 0)
+	atomic.StoreInt64(&c.scanMisses, 0)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+/* =====================================================================================
+   DIFF-AWARE SCAN FILTERING
+
+   DiffScan lets a caller ask for only the findings that land on lines a
+   PR actually changed, mirroring how reviewdog-style bots post comments:
+   run the full-file scan (semgrep needs the whole file for dataflow),
+   then post-filter by the unified diff.
+===================================================================================== */
+
+// lineRange is an inclusive [start, end] span of line numbers in a file's
+// post-diff (head) version.
+type lineRange struct {
+	start, end int
+}
+
+// changedRanges maps a file path to the line ranges a unified diff added
+// or modified.
+type changedRanges struct {
+	byFile map[string][]lineRange
+}
+
+// parseUnifiedDiff builds a changedRanges from a unified diff blob (the
+// format `git diff` and GitHub's compare API both produce). Only the
+// "+c,d" side of each "@@ -a,b +c,d @@" hunk header matters: that's the
+// line range in the post-diff file that a Finding.Line is reported
+// against.
+func parseUnifiedDiff(diff string) *changedRanges {
+	cr := &changedRanges{byFile: make(map[string][]lineRange)}
+
+	var currentFile string
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			currentFile = diffFilePath(line)
+		case strings.HasPrefix(line, "@@ "):
+			if currentFile == "" {
+				continue
+			}
+			if r, ok := parseHunkHeader(line); ok {
+				cr.byFile[currentFile] = append(cr.byFile[currentFile], r)
+			}
+		}
+	}
+
+	return cr
+}
+
+// diffFilePath strips the "+++ " marker, a leading "b/" prefix, and any
+// trailing tab `git diff` appends; a deleted file ("/dev/null") has no
+// post-diff lines to match, so it reports "".
+func diffFilePath(line string) string {
+	path := strings.TrimPrefix(line, "+++ ")
+	if idx := strings.IndexByte(path, '\t'); idx >= 0 {
+		path = path[:idx]
+	}
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(path, "b/")
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@ ..." into the +c,d added range.
+// A count of 0 (a pure deletion hunk, nothing added) reports no range.
+func parseHunkHeader(line string) (lineRange, bool) {
+	parts := strings.SplitN(line, "@@", 3)
+	if len(parts) < 2 {
+		return lineRange{}, false
+	}
+
+	for _, field := range strings.Fields(parts[1]) {
+		if !strings.HasPrefix(field, "+") {
+			continue
+		}
+
+		startStr, countStr, hasCount := strings.Cut(field[1:], ",")
+		start, err := strconv.Atoi(startStr)
+		if err != nil {
+			return lineRange{}, false
+		}
+
+		count := 1
+		if hasCount {
+			count, err = strconv.Atoi(countStr)
+			if err != nil {
+				return lineRange{}, false
+			}
+		}
+		if count == 0 {
+			return lineRange{}, false
+		}
+
+		return lineRange{start: start, end: start + count - 1}, true
+	}
+
+	return lineRange{}, false
+}
+
+// inChangedRange reports whether line in path falls inside a hunk added
+// by cr's diff. A path with no recorded hunks (not touched by the diff
+// at all) is treated as unchanged, so DiffScan only ever narrows
+// findings, never widens them.
+func inChangedRange(cr *changedRanges, path string, line int) bool {
+	for _, r := range cr.byFile[path] {
+		if line >= r.start && line <= r.end {
+			return true
+		}
+	}
+	return false
+}
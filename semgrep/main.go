@@ -8,15 +8,18 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 )
 
 // Server
 type SemgrepServer struct {
-	log zerolog.Logger
+	log   zerolog.Logger
+	cache FindingCache
 }
 
 // Request/Response types
@@ -24,7 +27,36 @@ type ScanRequest struct {
 	RepoPath string            `json:"repo_path"` // For reference/logging only
 	RepoURL  string            `json:"repo_url,omitempty"`
 	Branch   string            `json:"branch,omitempty"`
-	Files    map[string]string `json:"files"` // filename -> content
+	BaseSHA  string            `json:"base_sha,omitempty"` // enables --baseline-commit mode when set
+	Files    map[string]string `json:"files"`              // filename -> content
+
+	// OutputFormats selects which of markdown/sarif/json to populate on
+	// the response (FindingsMarkdown/Sarif/Findings respectively).
+	// Empty means all three, matching the service's original behavior.
+	OutputFormats []string `json:"output_formats,omitempty"`
+
+	// DiffScan narrows the reported findings down to only those whose
+	// line falls inside a hunk changed by UnifiedDiff, so a PR only sees
+	// comments on lines it actually touched (reviewdog-style). Findings
+	// outside the diff are still counted, just rolled up into
+	// ScanResponse.SeveritySuppressed instead of being reported.
+	DiffScan    bool   `json:"diff_scan,omitempty"`
+	UnifiedDiff string `json:"unified_diff,omitempty"`
+
+	// BaseRef/HeadRef are accepted as an alternative to UnifiedDiff, but
+	// this service only ever sees the file contents pushed in Files (no
+	// git checkout to diff against), so ref-based diffing isn't
+	// implemented yet — callers should resolve the diff client-side and
+	// pass UnifiedDiff until that lands.
+	BaseRef string `json:"base_ref,omitempty"`
+	HeadRef string `json:"head_ref,omitempty"`
+
+	// Configs overrides which Semgrep rulesets the scan runs, in place of
+	// defaultSemgrepConfigs. Entries not in allowedSemgrepConfigs are
+	// dropped server-side (with a warning) rather than forwarded to the
+	// Semgrep MCP endpoint, so a caller can't point this service's MCP
+	// credentials at an arbitrary, unvetted registry config.
+	Configs []string `json:"configs,omitempty"`
 }
 
 type ScanResponse struct {
@@ -33,7 +65,99 @@ type ScanResponse struct {
 	Severity         SemgrepSeveritySummary `json:"severity"`
 	FindingsCount    int                    `json:"findings_count"`
 	ScanDuration     string                 `json:"scan_duration"`
-	Error            string                 `json:"error,omitempty"`
+	Sarif            string                 `json:"sarif,omitempty"` // full SARIF 2.1.0 document, JSON-encoded
+	Findings         []Finding              `json:"findings,omitempty"`
+	// SeveritySuppressed tallies findings DiffScan filtered out for
+	// falling outside the changed lines. Zero value when DiffScan is off.
+	SeveritySuppressed SemgrepSeveritySummary `json:"severity_suppressed,omitempty"`
+	// PartialConfigs lists the Semgrep rulesets that failed or timed out
+	// during this scan. A non-empty PartialConfigs alongside Status
+	// "success" means the response is a partial result: the configs that
+	// did complete are reflected in Findings, but others may have found
+	// more.
+	PartialConfigs []string `json:"partial_configs,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Finding is a single structured result, normalized across every Scanner
+// so the orchestrator can anchor inline PR review comments, dedupe across
+// tools, and render SARIF without knowing which scanner produced it.
+type Finding struct {
+	RuleID      string `json:"rule_id"`
+	Path        string `json:"path"`
+	Line        int    `json:"line"`
+	EndLine     int    `json:"end_line,omitempty"`
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// wantsFormat reports whether format should be included in the response,
+// given the request's OutputFormats. An empty OutputFormats means every
+// format is wanted.
+func wantsFormat(formats []string, format string) bool {
+	if len(formats) == 0 {
+		return true
+	}
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// findingFromSemgrepResult normalizes one raw Semgrep result into the
+// scanner-agnostic Finding shape.
+func findingFromSemgrepResult(r semgrepResult) Finding {
+	sev := bucketSeverity(r.Extra.Severity)
+	return Finding{
+		RuleID:      r.CheckID,
+		Path:        r.Path,
+		Line:        r.Start.Line,
+		EndLine:     r.End.Line,
+		Severity:    sev,
+		Message:     r.Extra.Message,
+		Fingerprint: fingerprint(r.CheckID, r.Path, r.Start.Line),
+	}
+}
+
+// bucketSeverity maps a tool-reported severity string onto this service's
+// five-level scale (blocker/critical/major/minor/info).
+func bucketSeverity(sev string) string {
+	switch strings.ToLower(sev) {
+	case "blocker":
+		return "blocker"
+	case "error", "critical":
+		return "critical"
+	case "warning", "major":
+		return "major"
+	case "note", "minor":
+		return "minor"
+	default:
+		return "info"
+	}
+}
+
+// summarizeSeverity tallies a merged finding set into the response's
+// severity summary.
+func summarizeSeverity(findings []Finding) SemgrepSeveritySummary {
+	var s SemgrepSeveritySummary
+	for _, f := range findings {
+		switch f.Severity {
+		case "blocker":
+			s.Blocker++
+		case "critical":
+			s.Critical++
+		case "major":
+			s.Major++
+		case "minor":
+			s.Minor++
+		default:
+			s.Info++
+		}
+	}
+	return s
 }
 
 type SemgrepSeveritySummary struct {
@@ -58,8 +182,9 @@ type semgrepRPCParams struct {
 }
 
 type semgrepScanArgs struct {
-	CodeFiles []map[string]string `json:"code_files"`
-	Config    string              `json:"config,omitempty"`
+	CodeFiles      []map[string]string `json:"code_files"`
+	Config         string              `json:"config,omitempty"`
+	BaselineCommit string              `json:"baseline_commit,omitempty"`
 }
 
 type semgrepRPCResp struct {
@@ -85,6 +210,9 @@ type semgrepResult struct {
 	Start   struct {
 		Line int `json:"line"`
 	} `json:"start"`
+	End struct {
+		Line int `json:"line"`
+	} `json:"end"`
 	Extra struct {
 		Severity string `json:"severity"`
 		Message  string `json:"message"`
@@ -95,9 +223,34 @@ type semgrepResult struct {
 func main() {
 	logger := newLogger()
 
+	cacheMaxEntries := 5000
+	if cm := os.Getenv("CACHE_MAX_ENTRIES"); cm != "" {
+		if parsed, err := strconv.Atoi(cm); err == nil {
+			cacheMaxEntries = parsed
+		}
+	}
+	cacheTTLHours := 24
+	if ct := os.Getenv("CACHE_TTL_HOURS"); ct != "" {
+		if parsed, err := strconv.Atoi(ct); err == nil {
+			cacheTTLHours = parsed
+		}
+	}
+	redisURL := os.Getenv("CACHE_REDIS_URL")
+
+	fc, err := newFindingCache(cacheConfig{
+		MaxEntries: cacheMaxEntries,
+		TTL:        time.Duration(cacheTTLHours) * time.Hour,
+		RedisURL:   redisURL,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to construct finding cache")
+	}
+
 	server := &SemgrepServer{
-		log: logger,
+		log:   logger,
+		cache: fc,
 	}
+	warnIfMultiprocDirSet(server)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -107,9 +260,17 @@ func main() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", server.healthHandler)
 	mux.HandleFunc("/scan", server.scanHandler)
+	mux.HandleFunc("/scan/stream", server.scanStreamHandler)
+	mux.HandleFunc("/cache/stats", server.cacheStatsHandler)
+	mux.HandleFunc("/cache/purge", server.cachePurgeHandler)
+	mux.Handle(metricsPath(), promhttp.Handler())
 
 	addr := ":" + port
-	logger.Info().Str("port", port).Msg("Semgrep service starting")
+	cacheKind := "in-memory"
+	if redisURL != "" {
+		cacheKind = "redis"
+	}
+	logger.Info().Str("port", port).Str("cache", cacheKind).Str("metrics_path", metricsPath()).Msg("Semgrep service starting")
 
 	if err := http.ListenAndServe(addr, mux); err != nil {
 		logger.Fatal().Err(err).Msg("server failed to start")
@@ -170,14 +331,36 @@ func (s *SemgrepServer) scanHandler(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	startTime := time.Now()
-	result := s.performScan(ctx, req)
+	result := s.performScan(ctx, req, nil)
 	result.ScanDuration = time.Since(startTime).String()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
 
-func (s *SemgrepServer) performScan(ctx context.Context, req ScanRequest) ScanResponse {
+// performScan instruments performScanImpl with semgrep_scan_duration_seconds
+// and semgrep_scan_in_flight, and records the findings it returns. The
+// enabled scanner set (e.g. "semgrep") stands in for the duration metric's
+// "config" label, since a single scan can fan out across more than one
+// Scanner-internal Semgrep ruleset.
+func (s *SemgrepServer) performScan(ctx context.Context, req ScanRequest, sink EventSink) ScanResponse {
+	scanInFlight.Inc()
+	defer scanInFlight.Dec()
+
+	start := time.Now()
+	resp := s.performScanImpl(ctx, req, sink)
+
+	scanDuration.WithLabelValues(enabledScannerNames(), resp.Status).Observe(time.Since(start).Seconds())
+	observeFindings(resp.Findings)
+	return resp
+}
+
+// performScanImpl runs the scan and, when sink is non-nil, publishes
+// config_attempt/partial_findings progress events as scanners make
+// progress. The plain /scan endpoint passes a nil sink, so publish's
+// nil-safety keeps this function's behavior identical to before
+// /scan/stream existed.
+func (s *SemgrepServer) performScanImpl(ctx context.Context, req ScanRequest, sink EventSink) ScanResponse {
 	// Use files from request body
 	if len(req.Files) == 0 {
 		s.log.Warn().Msg("no code files provided")
@@ -191,111 +374,198 @@ func (s *SemgrepServer) performScan(ctx context.Context, req ScanRequest) ScanRe
 
 	s.log.Info().Int("file_count", len(req.Files)).Msg("processing files from request")
 
-	// Convert files map to codeFiles format for Semgrep
-	codeFiles := make([]map[string]string, 0, len(req.Files))
-	for filename, content := range req.Files {
-		codeFiles = append(codeFiles, map[string]string{
-			"filename": filename, // Semgrep expects "filename", not "path"
-			"content":  content,
-		})
-	}
-
-	s.log.Info().Int("files", len(codeFiles)).Msg("collected code files")
-
 	// Get Semgrep MCP URL
 	semgrepMCPURL := os.Getenv("SEMGREP_MCP_URL")
 	if semgrepMCPURL == "" {
 		semgrepMCPURL = "https://mcp.semgrep.ai/mcp"
 	}
 
-	// Try multiple Semgrep configurations
-	semgrepConfigs := []string{
-		"p/default",
-		"p/security-audit",
-		"p/ci",
-	}
+	configs := resolveSemgrepConfigs(req.Configs, s.log)
+	scanners := buildScanners(s, semgrepMCPURL, configs)
+	profile := scannerProfile(scanners) + "|" + strings.Join(configs, ",")
 
-	var parsed semgrepScanPayload
-	var lastError error
-
-	for _, cfg := range semgrepConfigs {
-		s.log.Debug().Str("config", cfg).Msg("trying Semgrep config")
+	// An identical ScanRequest (same files, same scanners) served before
+	// returns instantly from the scan-level cache.
+	scanKey := scanCacheKey(req, profile)
+	if cached, hit, err := s.cache.GetScan(ctx, scanKey); err != nil {
+		s.log.Warn().Err(err).Msg("scan cache lookup failed")
+	} else if hit {
+		s.log.Info().Msg("scan cache hit, skipping Semgrep entirely")
+		return cached
+	}
 
-		result, err := s.callSemgrepMCP(ctx, semgrepMCPURL, codeFiles, cfg)
+	// Otherwise fall back to the per-file cache: files whose content
+	// (under this scanner profile) was already scanned are served from
+	// there, and only the rest are sent to the scanners.
+	var cachedFindings []Finding
+	toScan := make(map[string]string, len(req.Files))
+	for filename, content := range req.Files {
+		fk := fileCacheKey(content, profile)
+		found, hit, err := s.cache.GetFile(ctx, fk)
 		if err != nil {
-			s.log.Warn().Err(err).Str("config", cfg).Msg("semgrep config failed")
-			lastError = err
+			s.log.Warn().Err(err).Str("file", filename).Msg("file cache lookup failed")
+			toScan[filename] = content
 			continue
 		}
+		if hit {
+			cachedFindings = append(cachedFindings, found...)
+			continue
+		}
+		toScan[filename] = content
+	}
+	s.log.Info().Int("cache_hits", len(req.Files)-len(toScan)).Int("cache_misses", len(toScan)).Msg("file cache lookup complete")
+
+	codeFiles := make([]map[string]string, 0, len(toScan))
+	for filename, content := range toScan {
+		codeFiles = append(codeFiles, map[string]string{
+			"filename": filename, // Semgrep expects "filename", not "path"
+			"content":  content,
+		})
+	}
 
-		if len(result.Results) > 0 {
-			parsed = result
-			s.log.Info().
-				Str("config", cfg).
-				Int("findings", len(result.Results)).
-				Msg("semgrep scan successful")
-			break
+	findings := cachedFindings
+	anySucceeded := true
+	var partialConfigs []string
+	if len(codeFiles) > 0 {
+		filesScannedTotal.Add(float64(len(codeFiles)))
+
+		var fresh []Finding
+		fresh, anySucceeded, partialConfigs = runScanners(ctx, s, scanners, codeFiles, req.BaseSHA, sink)
+		if anySucceeded {
+			s.cacheFreshFindings(ctx, toScan, fresh, profile)
 		}
+		findings = mergeFindings(cachedFindings, fresh)
+	}
+
+	var suppressed SemgrepSeveritySummary
+	if req.DiffScan {
+		findings, suppressed = s.filterToChangedLines(req, findings)
 	}
 
-	// No findings found
-	if len(parsed.Results) == 0 {
-		if lastError != nil {
-			s.log.Warn().Err(lastError).Msg("all semgrep configs failed")
+	if len(findings) == 0 {
+		if !anySucceeded {
+			s.log.Warn().Msg("all scanners failed")
 			return ScanResponse{
 				Status:           "error",
 				FindingsMarkdown: s.generateFallbackMarkdown(),
-				Error:            "Semgrep scan failed for all configurations",
+				Error:            "scan failed for every enabled scanner",
 			}
 		}
 
-		s.log.Info().Msg("semgrep found no issues")
-		return ScanResponse{
-			Status:           "success",
-			FindingsMarkdown: "No security issues found by Semgrep. âœ…",
-			FindingsCount:    0,
-			Severity:         SemgrepSeveritySummary{},
+		s.log.Info().Msg("no issues found")
+		resp := ScanResponse{
+			Status:             "success",
+			FindingsMarkdown:   "No security issues found by Semgrep. âœ…",
+			FindingsCount:      0,
+			Severity:           SemgrepSeveritySummary{},
+			SeveritySuppressed: suppressed,
+			PartialConfigs:     partialConfigs,
 		}
-	}
-
-	// Compute severity summary
-	severity := SemgrepSeveritySummary{}
-	for _, r := range parsed.Results {
-		switch strings.ToLower(r.Extra.Severity) {
-		case "blocker":
-			severity.Blocker++
-		case "error", "critical":
-			severity.Critical++
-		case "warning", "major":
-			severity.Major++
-		case "note", "minor":
-			severity.Minor++
-		default:
-			severity.Info++
+		if len(partialConfigs) == 0 {
+			// Only a clean, fully-completed scan is worth caching at the
+			// scan level — a partial one might find more on retry.
+			s.cacheScanResult(ctx, scanKey, resp)
 		}
+		return resp
 	}
 
-	// Format findings as markdown
-	markdown := s.formatSemgrepMarkdown(parsed.Results)
+	severity := summarizeSeverity(findings)
 
 	s.log.Info().
-		Int("total", len(parsed.Results)).
+		Int("total", len(findings)).
 		Int("blocker", severity.Blocker).
 		Int("critical", severity.Critical).
 		Int("major", severity.Major).
 		Int("minor", severity.Minor).
 		Int("info", severity.Info).
-		Msg("semgrep scan completed successfully")
+		Msg("scan completed successfully")
 
-	return ScanResponse{
-		Status:           "success",
-		FindingsMarkdown: markdown,
-		Severity:         severity,
-		FindingsCount:    len(parsed.Results),
+	resp := ScanResponse{
+		Status:             "success",
+		Severity:           severity,
+		FindingsCount:      len(findings),
+		SeveritySuppressed: suppressed,
+		PartialConfigs:     partialConfigs,
 	}
+
+	if wantsFormat(req.OutputFormats, "markdown") {
+		resp.FindingsMarkdown = s.formatFindingsMarkdown(findings)
+	}
+	if wantsFormat(req.OutputFormats, "sarif") {
+		sarifDoc, err := buildSarif(findings)
+		if err != nil {
+			s.log.Warn().Err(err).Msg("failed to build SARIF document")
+		}
+		resp.Sarif = sarifDoc
+	}
+	if wantsFormat(req.OutputFormats, "json") {
+		resp.Findings = findings
+	}
+
+	if len(partialConfigs) == 0 {
+		s.cacheScanResult(ctx, scanKey, resp)
+	}
+	return resp
 }
 
-func (s *SemgrepServer) callSemgrepMCP(ctx context.Context, url string, codeFiles []map[string]string, config string) (semgrepScanPayload, error) {
+// cacheFreshFindings stores each freshly-scanned file's findings back into
+// the file cache, keyed by that file's own content, so a later request
+// touching only some of these files can serve the rest from cache.
+func (s *SemgrepServer) cacheFreshFindings(ctx context.Context, scanned map[string]string, findings []Finding, profile string) {
+	byPath := make(map[string][]Finding, len(scanned))
+	for _, f := range findings {
+		byPath[f.Path] = append(byPath[f.Path], f)
+	}
+	for filename, content := range scanned {
+		fk := fileCacheKey(content, profile)
+		if err := s.cache.SetFile(ctx, fk, byPath[filename]); err != nil {
+			s.log.Warn().Err(err).Str("file", filename).Msg("failed to store file cache entry")
+		}
+	}
+}
+
+// cacheScanResult stores resp under scanKey so an identical ScanRequest
+// returns instantly next time.
+func (s *SemgrepServer) cacheScanResult(ctx context.Context, scanKey string, resp ScanResponse) {
+	if err := s.cache.SetScan(ctx, scanKey, resp); err != nil {
+		s.log.Warn().Err(err).Msg("failed to store scan cache entry")
+	}
+}
+
+// filterToChangedLines drops every finding outside the line ranges
+// UnifiedDiff marks as added/modified, returning the kept findings plus a
+// severity tally of everything it suppressed.
+func (s *SemgrepServer) filterToChangedLines(req ScanRequest, findings []Finding) (kept []Finding, suppressed SemgrepSeveritySummary) {
+	if req.UnifiedDiff == "" {
+		s.log.Warn().Msg("diff_scan requested without unified_diff; base_ref/head_ref diffing isn't implemented yet, skipping filter")
+		return findings, SemgrepSeveritySummary{}
+	}
+
+	cr := parseUnifiedDiff(req.UnifiedDiff)
+	var dropped []Finding
+	for _, f := range findings {
+		if inChangedRange(cr, f.Path, f.Line) {
+			kept = append(kept, f)
+		} else {
+			dropped = append(dropped, f)
+		}
+	}
+
+	s.log.Info().Int("kept", len(kept)).Int("suppressed", len(dropped)).Msg("diff_scan filtered findings to changed lines")
+	return kept, summarizeSeverity(dropped)
+}
+
+func (s *SemgrepServer) callSemgrepMCP(ctx context.Context, url string, codeFiles []map[string]string, config, baselineCommit string) (semgrepScanPayload, error) {
+	start := time.Now()
+	payload, err := s.callSemgrepMCPImpl(ctx, url, codeFiles, config, baselineCommit)
+	mcpRequestDuration.WithLabelValues(config).Observe(time.Since(start).Seconds())
+	if err != nil {
+		mcpErrorsTotal.WithLabelValues(config).Inc()
+	}
+	return payload, err
+}
+
+func (s *SemgrepServer) callSemgrepMCPImpl(ctx context.Context, url string, codeFiles []map[string]string, config, baselineCommit string) (semgrepScanPayload, error) {
 	reqBody := semgrepRPCReq{
 		JSONRPC: "2.0",
 		ID:      "semgrep_scan",
@@ -303,8 +573,9 @@ func (s *SemgrepServer) callSemgrepMCP(ctx context.Context, url string, codeFile
 		Params: semgrepRPCParams{
 			Name: "semgrep_scan",
 			Arguments: semgrepScanArgs{
-				CodeFiles: codeFiles,
-				Config:    config,
+				CodeFiles:      codeFiles,
+				Config:         config,
+				BaselineCommit: baselineCommit,
 			},
 		},
 	}
@@ -429,116 +700,64 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen] + "...(truncated)"
 }
 
-func (s *SemgrepServer) formatSemgrepMarkdown(results []semgrepResult) string {
-	if len(results) == 0 {
+func (s *SemgrepServer) formatFindingsMarkdown(findings []Finding) string {
+	if len(findings) == 0 {
 		return "No security issues found by Semgrep."
 	}
 
-	var sb strings.Builder
-
-	// Count by severity
-	var blocker, critical, major, minor, info int
-	for _, r := range results {
-		sev := strings.ToLower(r.Extra.Severity)
-		switch sev {
-		case "blocker":
-			blocker++
-		case "error", "critical":
-			critical++
-		case "warning", "major":
-			major++
-		case "note", "minor":
-			minor++
-		default:
-			info++
-		}
-	}
+	severity := summarizeSeverity(findings)
 
+	var sb strings.Builder
 	sb.WriteString("### Semgrep Summary\n\n")
 	sb.WriteString("**Issue Counts:**\n\n")
-	sb.WriteString("| ğŸš« Blocker | ğŸ”´ Critical | ğŸŸ  Major | ğŸŸ¡ Minor | â„¹ï¸ Info |\n")
+	sb.WriteString("| 🚫 Blocker | 🔴 Critical | 🟠 Major | 🟡 Minor | ℹ️ Info |\n")
 	sb.WriteString("|:----------:|:-----------:|:--------:|:--------:|:-------:|\n")
-
-	blockerStr := formatCount(blocker, true)
-	criticalStr := formatCount(critical, true)
-	majorStr := formatCount(major, true)
-	minorStr := formatCount(minor, false)
-	infoStr := formatCount(info, false)
-
 	sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n\n",
-		blockerStr, criticalStr, majorStr, minorStr, infoStr))
-
-	// Group results by severity
-	blockerIssues := []semgrepResult{}
-	criticalIssues := []semgrepResult{}
-	majorIssues := []semgrepResult{}
-	minorIssues := []semgrepResult{}
-	infoIssues := []semgrepResult{}
-
-	for _, r := range results {
-		sev := strings.ToLower(r.Extra.Severity)
-		switch sev {
+		formatCount(severity.Blocker, true),
+		formatCount(severity.Critical, true),
+		formatCount(severity.Major, true),
+		formatCount(severity.Minor, false),
+		formatCount(severity.Info, false)))
+
+	var blockerIssues, criticalIssues, majorIssues, minorIssues, infoIssues []Finding
+	for _, f := range findings {
+		switch f.Severity {
 		case "blocker":
-			blockerIssues = append(blockerIssues, r)
-		case "error", "critical":
-			criticalIssues = append(criticalIssues, r)
-		case "warning", "major":
-			majorIssues = append(majorIssues, r)
-		case "note", "minor":
-			minorIssues = append(minorIssues, r)
+			blockerIssues = append(blockerIssues, f)
+		case "critical":
+			criticalIssues = append(criticalIssues, f)
+		case "major":
+			majorIssues = append(majorIssues, f)
+		case "minor":
+			minorIssues = append(minorIssues, f)
 		default:
-			infoIssues = append(infoIssues, r)
+			infoIssues = append(infoIssues, f)
 		}
 	}
 
-	// Write blocker issues
-	if len(blockerIssues) > 0 {
-		sb.WriteString("### ğŸš« Blocker Issues\n\n")
-		for _, r := range blockerIssues {
-			sb.WriteString(fmt.Sprintf("- **%s** in `%s:%d`\n", r.Extra.Message, r.Path, r.Start.Line))
-			sb.WriteString(fmt.Sprintf("  - Rule: `%s`\n", r.CheckID))
-			if r.Extra.Lines != "" {
-				sb.WriteString(fmt.Sprintf("  - Code: `%s`\n", strings.TrimSpace(r.Extra.Lines)))
-			}
-			sb.WriteString("\n")
+	writeSection := func(title string, issues []Finding) {
+		if len(issues) == 0 {
+			return
 		}
-	}
-
-	// Write critical issues
-	if len(criticalIssues) > 0 {
-		sb.WriteString("### ğŸ”´ Critical Issues\n\n")
-		for _, r := range criticalIssues {
-			sb.WriteString(fmt.Sprintf("- **%s** in `%s:%d`\n", r.Extra.Message, r.Path, r.Start.Line))
-			sb.WriteString(fmt.Sprintf("  - Rule: `%s`\n", r.CheckID))
-			if r.Extra.Lines != "" {
-				sb.WriteString(fmt.Sprintf("  - Code: `%s`\n", strings.TrimSpace(r.Extra.Lines)))
-			}
+		sb.WriteString(title)
+		for _, f := range issues {
+			sb.WriteString(fmt.Sprintf("- **%s** in `%s:%d`\n", f.Message, f.Path, f.Line))
+			sb.WriteString(fmt.Sprintf("  - Rule: `%s`\n", f.RuleID))
 			sb.WriteString("\n")
 		}
 	}
 
-	// Write major issues
-	if len(majorIssues) > 0 {
-		sb.WriteString("### ğŸŸ  Major Issues\n\n")
-		for _, r := range majorIssues {
-			sb.WriteString(fmt.Sprintf("- **%s** in `%s:%d`\n", r.Extra.Message, r.Path, r.Start.Line))
-			sb.WriteString(fmt.Sprintf("  - Rule: `%s`\n", r.CheckID))
-			if r.Extra.Lines != "" {
-				lines := strings.TrimSpace(r.Extra.Lines)
-				if len(lines) > 0 {
-					sb.WriteString(fmt.Sprintf("  - Code: `%s`\n", lines))
-				}
-			}
-			sb.WriteString("\n")
-		}
-	}
+	writeSection("### 🚫 Blocker Issues\n\n", blockerIssues)
+	writeSection("### 🔴 Critical Issues\n\n", criticalIssues)
+	writeSection("### 🟠 Major Issues\n\n", majorIssues)
 
-	// Write minor issues (limited to first 5)
+	// Minor/info issues are capped so a noisy ruleset doesn't blow out the
+	// PR comment.
 	if len(minorIssues) > 0 {
-		sb.WriteString("### ğŸŸ¡ Minor Issues\n\n")
-		for i, r := range minorIssues {
+		sb.WriteString("### 🟡 Minor Issues\n\n")
+		for i, f := range minorIssues {
 			if i < 5 {
-				sb.WriteString(fmt.Sprintf("- %s in `%s:%d`\n", r.Extra.Message, r.Path, r.Start.Line))
+				sb.WriteString(fmt.Sprintf("- %s in `%s:%d`\n", f.Message, f.Path, f.Line))
 			}
 		}
 		if len(minorIssues) > 5 {
@@ -546,12 +765,11 @@ func (s *SemgrepServer) formatSemgrepMarkdown(results []semgrepResult) string {
 		}
 	}
 
-	// Write info issues (limited to first 3)
 	if len(infoIssues) > 0 {
-		sb.WriteString("### â„¹ï¸ Info\n\n")
-		for i, r := range infoIssues {
+		sb.WriteString("### ℹ️ Info\n\n")
+		for i, f := range infoIssues {
 			if i < 3 {
-				sb.WriteString(fmt.Sprintf("- %s in `%s:%d`\n", r.Extra.Message, r.Path, r.Start.Line))
+				sb.WriteString(fmt.Sprintf("- %s in `%s:%d`\n", f.Message, f.Path, f.Line))
 			}
 		}
 		if len(infoIssues) > 3 {
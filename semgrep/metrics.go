@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+/* =====================================================================================
+   METRICS
+
+   Gives operators the same visibility into scan latency and finding
+   volume that the gds_metrics-style wrappers give Python services.
+   promauto registers each metric against the default registry, so
+   promhttp.Handler() (wired up in main) picks them up alongside the Go
+   runtime/process collectors it already exposes.
+===================================================================================== */
+
+var (
+	scanDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "semgrep_scan_duration_seconds",
+		Help: "Latency of a full performScan call, by enabled scanner set (the config label) and outcome.",
+	}, []string{"config", "status"})
+
+	findingsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "semgrep_findings_total",
+		Help: "Findings returned by performScan, by severity and rule ID.",
+	}, []string{"severity", "rule_id"})
+
+	mcpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "semgrep_mcp_request_duration_seconds",
+		Help: "Latency of a single Semgrep MCP tools/call request, by config.",
+	}, []string{"config"})
+
+	mcpErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "semgrep_mcp_errors_total",
+		Help: "Semgrep MCP request failures, by config.",
+	}, []string{"config"})
+
+	filesScannedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "semgrep_files_scanned_total",
+		Help: "Files actually sent to a scanner (cache misses), cumulative across scans.",
+	})
+
+	scanInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "semgrep_scan_in_flight",
+		Help: "performScan calls currently running.",
+	})
+)
+
+// observeFindings records findingsTotal for one completed scan.
+func observeFindings(findings []Finding) {
+	for _, f := range findings {
+		findingsTotal.WithLabelValues(f.Severity, f.RuleID).Inc()
+	}
+}
+
+// metricsPath returns the path /metrics should be served on, honoring
+// PROMETHEUS_METRICS_PATH for deployments that route metrics scraping
+// differently than application traffic.
+func metricsPath() string {
+	if p := os.Getenv("PROMETHEUS_METRICS_PATH"); p != "" {
+		return p
+	}
+	return "/metrics"
+}
+
+// warnIfMultiprocDirSet logs that PROMETHEUS_MULTIPROC_DIR (a convention
+// from Python's prometheus_client for aggregating metrics across prefork
+// workers) has no equivalent here: this service runs as a single process
+// handling all traffic through one *http.ServeMux, so promauto's default
+// registry already reflects the whole process and there's no per-worker
+// aggregation step to do.
+func warnIfMultiprocDirSet(s *SemgrepServer) {
+	if dir := os.Getenv("PROMETHEUS_MULTIPROC_DIR"); dir != "" {
+		s.log.Warn().Str("dir", dir).Msg("PROMETHEUS_MULTIPROC_DIR is set but has no effect: this service is single-process, so metrics are already process-wide")
+	}
+}
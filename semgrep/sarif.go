@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Minimal SARIF 2.1.0 log structure — just enough of the schema for
+// GitHub's code-scanning upload endpoint to render the Security tab.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool   `json:"tool"`
+	Results []sarifFind `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string                 `json:"id"`
+	ShortDescription sarifMessage           `json:"shortDescription"`
+	DefaultConfig    sarifRuleConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifFind struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifLevel maps a Semgrep severity bucket to the error/warning/note
+// levels the SARIF spec (and GitHub's Security tab) expect.
+func sarifLevel(sev string) string {
+	switch sev {
+	case "blocker", "error", "critical":
+		return "error"
+	case "warning", "major":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// fingerprint derives a stable fingerprint from a rule and location so
+// GitHub can suppress duplicate findings across re-runs. It hashes the
+// same (rule, path, line) triple that mergeFindings dedupes on.
+func fingerprint(ruleID, path string, line int) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d", ruleID, path, line)))
+	return hex.EncodeToString(h[:])
+}
+
+// buildSarif converts a normalized, merged finding set into a SARIF 2.1.0
+// document, tagging the tool name as "semgrep" since that's still the
+// only scanner whose findings feed GitHub's code-scanning upload today.
+func buildSarif(findings []Finding) (string, error) {
+	rulesSeen := make(map[string]bool)
+	var rules []sarifRule
+	var results []sarifFind
+
+	for _, f := range findings {
+		level := sarifLevel(f.Severity)
+
+		if !rulesSeen[f.RuleID] {
+			rulesSeen[f.RuleID] = true
+			rules = append(rules, sarifRule{
+				ID:               f.RuleID,
+				ShortDescription: sarifMessage{Text: f.Message},
+				DefaultConfig:    sarifRuleConfiguration{Level: level},
+			})
+		}
+
+		results = append(results, sarifFind{
+			RuleID:  f.RuleID,
+			Level:   level,
+			Message: sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: f.Path},
+					Region:           sarifRegion{StartLine: f.Line},
+				},
+			}},
+			PartialFingerprints: map[string]string{
+				"semgrepFingerprint/v1": f.Fingerprint,
+			},
+		})
+	}
+
+	doc := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:  "semgrep",
+					Rules: rules,
+				},
+			},
+			Results: results,
+		}},
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("marshal sarif document: %w", err)
+	}
+	return string(raw), nil
+}
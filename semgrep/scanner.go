@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+)
+
+// Scanner is a pluggable static-analysis backend. performScan fans out to
+// every enabled Scanner and merges their normalized Findings, so gosec,
+// bandit, or a local semgrep CLI can sit alongside the semgrep MCP
+// integration without the rest of the service knowing the difference.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, codeFiles []map[string]string, baselineCommit string, sink EventSink) ([]Finding, error)
+}
+
+// ConfigPartialer is implemented by Scanners that internally fan out
+// across several named variants, like semgrepMCPScanner running multiple
+// rulesets. runScanners checks for it after Scan returns so
+// ScanResponse.PartialConfigs can report which variants failed or timed
+// out, without the Scanner interface itself needing a "configs" concept.
+type ConfigPartialer interface {
+	PartialConfigs() []string
+}
+
+// defaultSemgrepConfigs is used when ScanRequest.Configs is empty.
+var defaultSemgrepConfigs = []string{
+	"p/default",
+	"p/security-audit",
+	"p/ci",
+}
+
+// allowedSemgrepConfigs is every ruleset ScanRequest.Configs may select.
+// It exists so a caller can't point this service's hosted MCP credentials
+// at an arbitrary, unvetted registry config.
+var allowedSemgrepConfigs = map[string]bool{
+	"auto":             true,
+	"p/default":        true,
+	"p/security-audit": true,
+	"p/ci":             true,
+}
+
+// resolveSemgrepConfigs validates requested against allowedSemgrepConfigs,
+// dropping (with a warning) any entry not on the allowlist rather than
+// failing the whole scan over one bad value. An empty or fully-invalid
+// requested list falls back to defaultSemgrepConfigs.
+func resolveSemgrepConfigs(requested []string, log zerolog.Logger) []string {
+	if len(requested) == 0 {
+		return defaultSemgrepConfigs
+	}
+
+	var out []string
+	for _, c := range requested {
+		if allowedSemgrepConfigs[c] {
+			out = append(out, c)
+		} else {
+			log.Warn().Str("config", c).Msg("requested Semgrep config not in allowlist, skipping")
+		}
+	}
+	if len(out) == 0 {
+		return defaultSemgrepConfigs
+	}
+	return out
+}
+
+// configConcurrency bounds how many configs semgrepMCPScanner.Scan runs at
+// once, via SEMGREP_CONFIG_CONCURRENCY. Default is one goroutine per
+// config (effectively unbounded, since there are only ever a handful).
+func configConcurrency(numConfigs int) int {
+	limit := numConfigs
+	if raw := os.Getenv("SEMGREP_CONFIG_CONCURRENCY"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	if limit <= 0 || limit > numConfigs {
+		limit = numConfigs
+	}
+	return limit
+}
+
+// configTimeout bounds a single config's Semgrep MCP call via
+// SEMGREP_CONFIG_TIMEOUT_SECONDS (default 2 minutes), so one slow or
+// stuck ruleset is reported as partial instead of stalling the whole scan.
+func configTimeout() time.Duration {
+	seconds := 120
+	if raw := os.Getenv("SEMGREP_CONFIG_TIMEOUT_SECONDS"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			seconds = parsed
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// semgrepMCPScanner is the reference Scanner, backed by the hosted
+// Semgrep MCP tool. It runs every config in configs concurrently and
+// merges their results, so findings unique to one ruleset aren't hidden
+// behind another that happened to run first.
+type semgrepMCPScanner struct {
+	server  *SemgrepServer
+	mcpURL  string
+	configs []string
+
+	mu             sync.Mutex
+	partialConfigs []string // configs that failed or timed out in the last Scan
+}
+
+func (s *semgrepMCPScanner) Name() string { return "semgrep" }
+
+// configOutcome pairs one config's raw results with its error so a
+// failure in one config doesn't discard the others'.
+type configOutcome struct {
+	config  string
+	results []semgrepResult
+	err     error
+}
+
+func (s *semgrepMCPScanner) Scan(ctx context.Context, codeFiles []map[string]string, baselineCommit string, sink EventSink) ([]Finding, error) {
+	configs := s.configs
+	if len(configs) == 0 {
+		configs = defaultSemgrepConfigs
+	}
+
+	outcomes := make([]configOutcome, len(configs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(configConcurrency(len(configs)))
+	for i, cfg := range configs {
+		i, cfg := i, cfg
+		g.Go(func() error {
+			s.server.log.Debug().Str("config", cfg).Msg("trying Semgrep config")
+			publish(sink, "config_attempt", map[string]string{"scanner": s.Name(), "config": cfg})
+
+			cctx, cancel := context.WithTimeout(gctx, configTimeout())
+			defer cancel()
+
+			result, err := s.server.callSemgrepMCP(cctx, s.mcpURL, codeFiles, cfg, baselineCommit)
+			outcomes[i] = configOutcome{config: cfg, results: result.Results, err: err}
+			return nil // a failed/timed-out config is reported as partial, not fatal to the others
+		})
+	}
+	_ = g.Wait()
+
+	seen := make(map[string]bool)
+	var merged []semgrepResult
+	var partial []string
+	var lastErr error
+	for _, o := range outcomes {
+		if o.err != nil {
+			s.server.log.Warn().Err(o.err).Str("config", o.config).Msg("semgrep config failed or timed out")
+			partial = append(partial, o.config)
+			lastErr = o.err
+			continue
+		}
+		for _, r := range o.results {
+			key := r.CheckID + "|" + r.Path + "|" + strconv.Itoa(r.Start.Line)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, r)
+		}
+	}
+
+	s.mu.Lock()
+	s.partialConfigs = partial
+	s.mu.Unlock()
+
+	if len(merged) == 0 && len(partial) == len(configs) {
+		return nil, lastErr // every config failed or timed out
+	}
+
+	findings := make([]Finding, 0, len(merged))
+	for _, r := range merged {
+		findings = append(findings, findingFromSemgrepResult(r))
+	}
+	if len(findings) > 0 {
+		s.server.log.Info().Int("findings", len(findings)).Int("configs", len(configs)).Msg("semgrep scan successful")
+		publish(sink, "partial_findings", map[string]interface{}{
+			"scanner": s.Name(),
+			"count":   len(findings),
+		})
+	}
+	return findings, nil
+}
+
+// PartialConfigs implements ConfigPartialer, reporting which configs
+// failed or timed out during the most recent Scan call.
+func (s *semgrepMCPScanner) PartialConfigs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.partialConfigs
+}
+
+// scannerNames reads SCANNERS (comma separated, default "semgrep") into
+// an ordered list of requested scanner names, before any name is checked
+// against the registry of known Scanner implementations.
+func scannerNames() []string {
+	names := []string{"semgrep"}
+	if raw := os.Getenv("SCANNERS"); raw != "" {
+		names = nil
+		for _, name := range strings.Split(raw, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// enabledScannerNames joins scannerNames for use as a metrics label.
+func enabledScannerNames() string {
+	return strings.Join(scannerNames(), ",")
+}
+
+// buildScanners constructs one Scanner per name in SCANNERS (comma
+// separated, default "semgrep"). Unknown names are skipped with a
+// warning rather than failing the whole scan. configs is forwarded to the
+// "semgrep" scanner as its set of rulesets; other scanner kinds ignore it.
+func buildScanners(s *SemgrepServer, mcpURL string, configs []string) []Scanner {
+	var scanners []Scanner
+	for _, name := range scannerNames() {
+		switch name {
+		case "semgrep":
+			scanners = append(scanners, &semgrepMCPScanner{server: s, mcpURL: mcpURL, configs: configs})
+		default:
+			s.log.Warn().Str("scanner", name).Msg("unknown scanner, skipping (no Scanner implementation registered)")
+		}
+	}
+	return scanners
+}
+
+// scanOutcome pairs a scanner's name with its result (or error) so a
+// failure in one scanner doesn't discard the others' findings.
+type scanOutcome struct {
+	name     string
+	findings []Finding
+	err      error
+}
+
+// runScanners runs every scanner concurrently and returns their combined,
+// deduplicated findings, whether at least one scanner succeeded, and the
+// union of every ConfigPartialer scanner's PartialConfigs.
+func runScanners(ctx context.Context, s *SemgrepServer, scanners []Scanner, codeFiles []map[string]string, baselineCommit string, sink EventSink) (findings []Finding, anySucceeded bool, partialConfigs []string) {
+	outcomes := make([]scanOutcome, len(scanners))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, sc := range scanners {
+		i, sc := i, sc
+		g.Go(func() error {
+			found, err := sc.Scan(gctx, codeFiles, baselineCommit, sink)
+			outcomes[i] = scanOutcome{name: sc.Name(), findings: found, err: err}
+			return nil // per-scanner errors don't cancel the other scanners
+		})
+	}
+	_ = g.Wait() // errors are carried in outcomes, not returned
+
+	var merged [][]Finding
+	for _, o := range outcomes {
+		if o.err != nil {
+			s.log.Error().Err(o.err).Str("scanner", o.name).Msg("scanner failed")
+			continue
+		}
+		anySucceeded = true
+		merged = append(merged, o.findings)
+	}
+
+	for _, sc := range scanners {
+		if p, ok := sc.(ConfigPartialer); ok {
+			partialConfigs = append(partialConfigs, p.PartialConfigs()...)
+		}
+	}
+
+	return mergeFindings(merged...), anySucceeded, partialConfigs
+}
+
+// mergeFindings concatenates findings from every scanner and deduplicates
+// them by (path, line, rule id), keeping the first occurrence so the
+// reference semgrep scanner wins ties over later-listed scanners.
+func mergeFindings(groups ...[]Finding) []Finding {
+	seen := make(map[string]bool)
+	var out []Finding
+	for _, group := range groups {
+		for _, f := range group {
+			key := f.Path + "|" + strconv.Itoa(f.Line) + "|" + f.RuleID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
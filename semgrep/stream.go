@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/* =====================================================================================
+   STREAMING SCAN PROGRESS
+
+   /scan/stream mirrors /scan's request contract but negotiates
+   text/event-stream and reports progress incrementally, since a scan that
+   drives several Scanners (and, within semgrepMCPScanner, several
+   semgrep configs run concurrently) can take most of the 10 minute scanHandler
+   timeout with no client-visible signal until the final response.
+===================================================================================== */
+
+// Event is one JSON-RPC-style notification published over the course of a
+// scan: scan_started, config_attempt, partial_findings, or scan_complete.
+type Event struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// EventSink receives progress events as a scan runs. performScan and the
+// Scanners it drives all take a possibly-nil EventSink, so publish is the
+// only thing that needs to guard against a nil sink on the plain /scan path.
+type EventSink interface {
+	Publish(event Event)
+}
+
+// publish is a nil-safe wrapper so scan code doesn't need an "if sink !=
+// nil" guard around every event it raises.
+func publish(sink EventSink, method string, params interface{}) {
+	if sink == nil {
+		return
+	}
+	sink.Publish(Event{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+const (
+	// maxStoredEvents bounds the ring buffer, capping how far back a
+	// reconnecting client can resume.
+	maxStoredEvents = 256
+	// streamRetention is how long a finished scan's eventBus (and its
+	// backlog) stays around for a late reconnect before being dropped.
+	streamRetention = 2 * time.Minute
+	keepaliveEvery  = 15 * time.Second
+)
+
+type storedEvent struct {
+	id    int
+	frame []byte
+}
+
+// eventBus fans a scan's events out to every currently-connected client
+// (the one that started the scan, plus any later /scan/stream GET
+// reconnects) and keeps a bounded backlog so a reconnect can replay
+// everything after its Last-Event-ID.
+type eventBus struct {
+	mu       sync.Mutex
+	ring     []storedEvent
+	subs     map[chan []byte]struct{}
+	nextID   int
+	finished chan struct{}
+	once     sync.Once
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[chan []byte]struct{}), finished: make(chan struct{})}
+}
+
+// Publish implements EventSink. A slow subscriber misses the live frame
+// but can still catch up via its next Last-Event-ID resume.
+func (b *eventBus) Publish(event Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	frame := []byte(fmt.Sprintf("id: %d\ndata: %s\n\n", b.nextID, payload))
+	b.ring = append(b.ring, storedEvent{id: b.nextID, frame: frame})
+	if len(b.ring) > maxStoredEvents {
+		b.ring = b.ring[len(b.ring)-maxStoredEvents:]
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- frame:
+		default:
+		}
+	}
+}
+
+// subscribe registers a new listener and returns the backlog of frames
+// already published with id > lastID.
+func (b *eventBus) subscribe(lastID int) (ch chan []byte, backlog [][]byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, e := range b.ring {
+		if e.id > lastID {
+			backlog = append(backlog, e.frame)
+		}
+	}
+	ch = make(chan []byte, 32)
+	b.subs[ch] = struct{}{}
+	return ch, backlog
+}
+
+func (b *eventBus) unsubscribe(ch chan []byte) {
+	b.mu.Lock()
+	delete(b.subs, ch)
+	b.mu.Unlock()
+}
+
+// markDone closes finished exactly once, signalling every current and
+// future subscriber that no more events are coming.
+func (b *eventBus) markDone() {
+	b.once.Do(func() { close(b.finished) })
+}
+
+// scanStreams tracks the eventBus for every scan currently streaming (or
+// recently finished, until streamRetention elapses), keyed by the scan id
+// handed to the client in the scan_started event.
+var scanStreams = struct {
+	mu      sync.Mutex
+	streams map[string]*eventBus
+}{streams: make(map[string]*eventBus)}
+
+func registerStream(scanID string) *eventBus {
+	bus := newEventBus()
+	scanStreams.mu.Lock()
+	scanStreams.streams[scanID] = bus
+	scanStreams.mu.Unlock()
+	return bus
+}
+
+func lookupStream(scanID string) (*eventBus, bool) {
+	scanStreams.mu.Lock()
+	defer scanStreams.mu.Unlock()
+	bus, ok := scanStreams.streams[scanID]
+	return bus, ok
+}
+
+func unregisterStream(scanID string) {
+	scanStreams.mu.Lock()
+	delete(scanStreams.streams, scanID)
+	scanStreams.mu.Unlock()
+}
+
+// newScanID returns a random 16-byte hex id. Good enough to key an
+// in-memory ring buffer for a few minutes; not worth a UUID dependency.
+func newScanID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// scanStreamHandler serves both halves of the SSE contract on one route:
+// POST starts a new scan and streams its progress; GET reconnects an
+// existing scan_id (optionally resuming after Last-Event-ID) without
+// starting another scan.
+func (s *SemgrepServer) scanStreamHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.startScanStream(w, r)
+	case http.MethodGet:
+		s.resumeScanStream(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *SemgrepServer) startScanStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var req ScanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.log.Error().Err(err).Msg("failed to decode request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.RepoPath == "" {
+		http.Error(w, "repo_path is required", http.StatusBadRequest)
+		return
+	}
+
+	scanID, err := newScanID()
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to allocate scan id")
+		http.Error(w, "failed to allocate scan id", http.StatusInternalServerError)
+		return
+	}
+	bus := registerStream(scanID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(http.StatusOK)
+
+	ch, _ := bus.subscribe(0)
+	bus.Publish(Event{JSONRPC: "2.0", Method: "scan_started", Params: map[string]string{"scan_id": scanID}})
+
+	// The scan runs on its own context so a dropped client doesn't abort
+	// it — a later GET reconnect with the same scan_id should still see
+	// it through to scan_complete.
+	go func() {
+		scanCtx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		startTime := time.Now()
+		result := s.performScan(scanCtx, req, bus)
+		result.ScanDuration = time.Since(startTime).String()
+
+		bus.Publish(Event{JSONRPC: "2.0", Method: "scan_complete", Params: result})
+		bus.markDone()
+		s.log.Info().Str("scan_id", scanID).Msg("scan stream completed")
+
+		time.AfterFunc(streamRetention, func() { unregisterStream(scanID) })
+	}()
+
+	s.streamEvents(w, flusher, r, bus, ch, nil)
+}
+
+func (s *SemgrepServer) resumeScanStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	scanID := r.URL.Query().Get("scan_id")
+	if scanID == "" {
+		http.Error(w, "scan_id is required", http.StatusBadRequest)
+		return
+	}
+	bus, ok := lookupStream(scanID)
+	if !ok {
+		http.Error(w, "unknown or expired scan_id", http.StatusNotFound)
+		return
+	}
+
+	lastID := parseLastEventID(r)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(http.StatusOK)
+
+	ch, backlog := bus.subscribe(lastID)
+	s.streamEvents(w, flusher, r, bus, ch, backlog)
+}
+
+// parseLastEventID reads the standard SSE reconnect header, falling back
+// to a ?last_event_id= query param for clients that can't set headers on
+// an EventSource GET.
+func parseLastEventID(r *http.Request) int {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	id, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// streamEvents writes backlog, then forwards live frames from ch until
+// the scan finishes or the client disconnects. It sends a
+// keepalive comment on the idle ticker so proxies don't time out the
+// connection during a long scan.
+func (s *SemgrepServer) streamEvents(w http.ResponseWriter, flusher http.Flusher, r *http.Request, bus *eventBus, ch chan []byte, backlog [][]byte) {
+	defer bus.unsubscribe(ch)
+
+	for _, frame := range backlog {
+		w.Write(frame)
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepaliveEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case frame := <-ch:
+			w.Write(frame)
+			flusher.Flush()
+		case <-ticker.C:
+			w.Write([]byte(": keepalive\n\n"))
+			flusher.Flush()
+		case <-bus.finished:
+			// Drain whatever's already queued, then we're done.
+			for {
+				select {
+				case frame := <-ch:
+					w.Write(frame)
+					flusher.Flush()
+				default:
+					return
+				}
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
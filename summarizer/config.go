@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// configPath is the -c/--config flag: a YAML, TOML, or JSON file (extension
+// picks the format) holding the nested config schema below. It's optional;
+// every field also has an env var fallback via loadConfigFromEnv, matched
+// through viper's AutomaticEnv.
+var configPath = flag.String("config", "", "path to a YAML/TOML/JSON config file")
+
+func init() {
+	flag.StringVar(configPath, "c", "", "shorthand for --config")
+}
+
+// newViper builds a viper instance seeded with every current env-var
+// default (so a deployment with no config file behaves exactly as before)
+// and bound to the nested keys the file format introduces: llm.model,
+// llm.max_tokens, llm.system_prompt_file.
+func newViper(envDefaults Config) *viper.Viper {
+	v := viper.New()
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	v.SetDefault("listen_addr", envDefaults.ListenAddr)
+	v.SetDefault("anthropic_api_key", envDefaults.AnthropicKey)
+	v.SetDefault("llm.model", envDefaults.Model)
+	v.SetDefault("llm.max_tokens", envDefaults.MaxTokens)
+	v.SetDefault("llm.system_prompt_file", "")
+	v.SetDefault("llm.rate_limit_rps", envDefaults.RateLimitRPS)
+	v.SetDefault("llm.rate_limit_burst", envDefaults.RateLimitBurst)
+	v.SetDefault("summarizer_prompt", envDefaults.SummarizerPrompt)
+	v.SetDefault("checkpoint_dir", envDefaults.CheckpointDir)
+
+	return v
+}
+
+// configFromViper reads every key newViper seeded back out, applying
+// whatever a config file or an AutomaticEnv-matched env var overrode. If
+// llm.system_prompt_file is set, its contents replace summarizer_prompt so
+// the prompt can be edited on disk without touching the config file itself.
+// The prompt file is read when the config is loaded or reloaded.
+func configFromViper(v *viper.Viper) Config {
+	prompt := v.GetString("summarizer_prompt")
+	if path := v.GetString("llm.system_prompt_file"); path != "" {
+		if b, err := os.ReadFile(path); err != nil {
+			log.Printf("⚠️ Warning: failed to read llm.system_prompt_file %q, keeping previous prompt: %v", path, err)
+		} else {
+			prompt = string(b)
+		}
+	}
+
+	return Config{
+		ListenAddr:       v.GetString("listen_addr"),
+		AnthropicKey:     v.GetString("anthropic_api_key"),
+		Model:            v.GetString("llm.model"),
+		MaxTokens:        v.GetInt("llm.max_tokens"),
+		RateLimitRPS:     v.GetFloat64("llm.rate_limit_rps"),
+		RateLimitBurst:   v.GetInt("llm.rate_limit_burst"),
+		CheckpointDir:    v.GetString("checkpoint_dir"),
+		SummarizerPrompt: prompt,
+	}
+}
+
+// loadConfig builds the initial Config from, in increasing priority:
+// hardcoded defaults, the env vars loadConfigFromEnv already reads, and (if
+// -c/--config was given) a YAML/TOML/JSON file. An env var whose name
+// matches a key (LISTEN_ADDR, ANTHROPIC_API_KEY, SUMMARIZER_PROMPT, or
+// LLM_MODEL for llm.model) still overrides the file, since viper consults
+// AutomaticEnv before it. v is returned so watchConfigFile can re-read the
+// same file on change.
+func loadConfig() (Config, *viper.Viper) {
+	v := newViper(loadConfigFromEnv())
+
+	if *configPath != "" {
+		v.SetConfigFile(*configPath)
+		_ = v.ReadInConfig() // missing/invalid file just falls back to defaults+env
+	}
+
+	return configFromViper(v), v
+}
+
+// watchConfigFile watches configPath's directory (not the file itself --
+// editors and orchestration tools like Kubernetes ConfigMap reloaders
+// replace the file via rename rather than writing in place, which a direct
+// file watch would miss) and atomically swaps store's value on every
+// change, so a model/prompt edit takes effect on the next request without
+// restarting the service.
+func watchConfigFile(v *viper.Viper, store *atomic.Value) {
+	if *configPath == "" {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("⚠️ Warning: failed to start config file watcher, hot reload disabled: %v", err)
+		return
+	}
+
+	dir := filepath.Dir(*configPath)
+	target := filepath.Clean(*configPath)
+	if err := watcher.Add(dir); err != nil {
+		log.Printf("⚠️ Warning: failed to watch config directory %q, hot reload disabled: %v", dir, err)
+		watcher.Close()
+		return
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != target {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+
+				if err := v.ReadInConfig(); err != nil {
+					log.Printf("⚠️ Warning: config file changed but failed to reload, keeping previous config: %v", err)
+					continue
+				}
+				store.Store(configFromViper(v))
+				log.Printf("🔄 Config file reloaded from %s", *configPath)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("⚠️ Warning: config file watcher error: %v", err)
+			}
+		}
+	}()
+}
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Config struct {
@@ -19,11 +26,24 @@ type Config struct {
 
 	AnthropicKey string
 	Model        string
+	MaxTokens    int
+
+	// RateLimitRPS and RateLimitBurst size the token bucket in
+	// resilience.go that throttles outbound Anthropic calls, independent
+	// of Anthropic's own 429s.
+	RateLimitRPS   float64
+	RateLimitBurst int
+
+	// CheckpointDir is where callLLM writes each run's streamed partial
+	// markdown (CheckpointDir/{run_id}/summary.partial.md), so a dropped
+	// connection or timeout can resume from the last flushed chunk
+	// instead of restarting the whole summary from scratch.
+	CheckpointDir string
 
 	SummarizerPrompt string
 }
 
-func loadConfig() Config {
+func loadConfigFromEnv() Config {
 	addr := os.Getenv("LISTEN_ADDR")
 	if addr == "" {
 		addr = ":80"
@@ -39,14 +59,39 @@ func loadConfig() Config {
 		prompt = defaultSummarizerPrompt
 	}
 
+	rateLimitRPS := 2.0
+	if raw := os.Getenv("ANTHROPIC_RATE_LIMIT_RPS"); raw != "" {
+		if parsed, err := strconv.ParseFloat(raw, 64); err == nil && parsed > 0 {
+			rateLimitRPS = parsed
+		}
+	}
+
+	rateLimitBurst := 4
+	if raw := os.Getenv("ANTHROPIC_RATE_LIMIT_BURST"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			rateLimitBurst = parsed
+		}
+	}
+
 	return Config{
 		ListenAddr:       addr,
 		AnthropicKey:     os.Getenv("ANTHROPIC_API_KEY"),
 		Model:            model,
+		MaxTokens:        4096,
+		RateLimitRPS:     rateLimitRPS,
+		RateLimitBurst:   rateLimitBurst,
+		CheckpointDir:    envOr("SUMMARY_CHECKPOINT_DIR", "/var/lib/orchestrator/runs"),
 		SummarizerPrompt: prompt,
 	}
 }
 
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 type PRMetadata struct {
 	RepoOwner    string `json:"repo_owner"`
 	RepoName     string `json:"repo_name"`
@@ -68,17 +113,41 @@ type SemgrepSeverity struct {
 	Info     int `json:"info"`
 }
 
+// AnalyzerReport is one static-analysis tool's contribution to a run: its
+// own markdown section and severity counts, plus the raw SARIF it came
+// from if any. MATCHES orchestrator/internal's AnalyzerReport -- adding a
+// new analyzer there needs no change here, since this handler just
+// iterates the slice.
+type AnalyzerReport struct {
+	Name        string          `json:"name"`
+	ToolVersion string          `json:"tool_version,omitempty"`
+	Markdown    string          `json:"markdown"`
+	Severity    SemgrepSeverity `json:"severity"`
+	SARIF       json.RawMessage `json:"sarif,omitempty"`
+}
+
 type SummarizerRequest struct {
-	PR                  PRMetadata      `json:"pr"`
-	DescriptionMarkdown string          `json:"description_markdown"`
-	ReviewMarkdown      string          `json:"review_markdown"`
-	SemgrepMarkdown     string          `json:"semgrep_markdown"`
-	SonarQubeMarkdown   string          `json:"sonarqube_markdown,omitempty"`
-	SemgrepSeverity     SemgrepSeverity `json:"semgrep_severity"`
+	PR                  PRMetadata       `json:"pr"`
+	DescriptionMarkdown string           `json:"description_markdown"`
+	ReviewMarkdown      string           `json:"review_markdown"`
+	AnalyzerReports     []AnalyzerReport `json:"analyzer_reports"`
+
+	// RunID and ProgressURL, when set, let callLLM checkpoint its
+	// streamed response to disk and POST partial markdown back to the
+	// orchestrator as it's generated. Both are optional: an empty RunID
+	// just disables checkpointing/resume for that call.
+	RunID       string `json:"run_id,omitempty"`
+	ProgressURL string `json:"progress_url,omitempty"`
+}
+
+type TokenUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
 
 type SummarizerResponse struct {
-	Markdown string `json:"markdown"` // MATCHES orchestrator's SummarizerOut
+	Markdown string      `json:"markdown"` // MATCHES orchestrator's SummarizerOut
+	Usage    *TokenUsage `json:"token_usage,omitempty"`
 }
 
 type anthropicMessage struct {
@@ -91,19 +160,95 @@ type anthropicRequest struct {
 	MaxTokens int                `json:"max_tokens"`
 	System    string             `json:"system"`
 	Messages  []anthropicMessage `json:"messages"`
+	Stream    bool               `json:"stream"`
 }
 
-type anthropicResponse struct {
-	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"content"`
+// anthropicStreamEvent covers the handful of SSE event shapes callLLM
+// cares about from Anthropic's streaming Messages API: message_start
+// (input token count), content_block_delta (the actual text), and
+// message_delta (final output token count). Fields irrelevant to a given
+// event type are simply left zero.
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Text         string `json:"text"`
+		OutputTokens int    `json:"output_tokens"`
+	} `json:"delta"`
+	Message struct {
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
 	Usage struct {
-		InputTokens  int `json:"input_tokens"`
 		OutputTokens int `json:"output_tokens"`
 	} `json:"usage"`
 }
 
+// streamTarget identifies where callLLM should checkpoint and report the
+// progress of a streamed response. Both fields may be empty.
+type streamTarget struct {
+	runID       string
+	progressURL string
+}
+
+func (cfg Config) checkpointPath(runID string) string {
+	return filepath.Join(cfg.CheckpointDir, runID, "summary.partial.md")
+}
+
+// readCheckpoint returns any partial markdown left over from a previous,
+// interrupted attempt at this run's summary, or "" if there is none.
+func (cfg Config) readCheckpoint(runID string) string {
+	if runID == "" {
+		return ""
+	}
+	b, err := os.ReadFile(cfg.checkpointPath(runID))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (cfg Config) writeCheckpoint(runID, partial string) {
+	if runID == "" {
+		return
+	}
+	path := cfg.checkpointPath(runID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Printf("⚠️ failed to create checkpoint dir for run %s: %v", runID, err)
+		return
+	}
+	if err := os.WriteFile(path, []byte(partial), 0o644); err != nil {
+		log.Printf("⚠️ failed to write checkpoint for run %s: %v", runID, err)
+	}
+}
+
+// postProgress best-effort POSTs the partial markdown so far to the
+// orchestrator's reviewapi progress endpoint. Failures are logged and
+// swallowed -- the checkpoint file on disk is the durable copy; this is
+// only for live SSE watchers.
+func postProgress(progressURL, partial string) {
+	if progressURL == "" {
+		return
+	}
+	encoded, err := json.Marshal(struct {
+		Markdown string `json:"partial_markdown"`
+	}{Markdown: partial})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, progressURL, bytes.NewReader(encoded))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("⚠️ progress callback to %s failed: %v", progressURL, err)
+		return
+	}
+	resp.Body.Close()
+}
+
 func doConnectivityCheck() {
 	log.Printf("🧪 DNS: api.anthropic.com ...")
 	addrs, err := net.LookupIP("api.anthropic.com")
@@ -123,18 +268,35 @@ func doConnectivityCheck() {
 	}
 }
 
-func callLLM(ctx context.Context, cfg Config, systemPrompt, userPrompt string) (string, error) {
+// callLLM streams the Anthropic response rather than waiting for it whole:
+// partial markdown is flushed to a per-run checkpoint file (and, if
+// target.progressURL is set, POSTed back to the orchestrator) as each
+// content_block_delta arrives. If target.runID has a checkpoint left over
+// from a prior, interrupted attempt, that partial text is prepended as an
+// assistant turn and the model is asked to continue rather than restart.
+// On ctx's deadline expiring mid-stream, the partial text accumulated so
+// far is returned instead of being discarded.
+func callLLM(ctx context.Context, cfg Config, systemPrompt, userPrompt string, target streamTarget) (string, *TokenUsage, error) {
 	if cfg.AnthropicKey == "" {
-		return "", fmt.Errorf("missing ANTHROPIC_API_KEY")
+		return "", nil, fmt.Errorf("missing ANTHROPIC_API_KEY")
+	}
+
+	messages := []anthropicMessage{{Role: "user", Content: userPrompt}}
+	partial := cfg.readCheckpoint(target.runID)
+	if partial != "" {
+		log.Printf("🔁 resuming run %s from a %d-byte checkpoint", target.runID, len(partial))
+		messages = append(messages,
+			anthropicMessage{Role: "assistant", Content: partial},
+			anthropicMessage{Role: "user", Content: "Continue exactly where you left off. Do not repeat any text already written."},
+		)
 	}
 
 	reqData := anthropicRequest{
 		Model:     cfg.Model,
-		MaxTokens: 4096,
+		MaxTokens: cfg.MaxTokens,
 		System:    systemPrompt,
-		Messages: []anthropicMessage{
-			{Role: "user", Content: userPrompt},
-		},
+		Messages:  messages,
+		Stream:    true,
 	}
 
 	body, _ := json.Marshal(reqData)
@@ -148,60 +310,112 @@ func callLLM(ctx context.Context, cfg Config, systemPrompt, userPrompt string) (
 	}
 	log.Printf("%s", out)
 
-	req, err := http.NewRequestWithContext(ctx, "POST",
-		"https://api.anthropic.com/v1/messages",
-		bytes.NewReader(body),
-	)
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("x-api-key", cfg.AnthropicKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := doAnthropicRequest(ctx, cfg, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST",
+			"https://api.anthropic.com/v1/messages",
+			bytes.NewReader(body),
+		)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("x-api-key", cfg.AnthropicKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
-		return "", err
+		return "", nil, fmt.Errorf("Anthropic request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	log.Printf("🌐 Returned: %d %s", resp.StatusCode, resp.Status)
 
-	if resp.StatusCode >= 300 {
-		b, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Anthropic error: %s", b)
+	var buf bytes.Buffer
+	buf.WriteString(partial)
+	var usage TokenUsage
+
+	const checkpointEvery = 500 * time.Millisecond
+	lastCheckpoint := time.Now()
+	flush := func() {
+		cfg.writeCheckpoint(target.runID, buf.String())
+		postProgress(target.progressURL, buf.String())
 	}
 
-	var parsed anthropicResponse
-	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return "", err
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+
+		var evt anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &evt); err != nil {
+			continue
+		}
+
+		switch evt.Type {
+		case "message_start":
+			usage.InputTokens = evt.Message.Usage.InputTokens
+		case "content_block_delta":
+			buf.WriteString(evt.Delta.Text)
+			if time.Since(lastCheckpoint) >= checkpointEvery {
+				flush()
+				lastCheckpoint = time.Now()
+			}
+		case "message_delta":
+			if evt.Usage.OutputTokens > 0 {
+				usage.OutputTokens = evt.Usage.OutputTokens
+			}
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
 	}
-	if len(parsed.Content) == 0 {
-		return "", fmt.Errorf("Anthropic returned no content")
+	flush()
+
+	// A hard deadline guard: if the stream was cut short by ctx expiring
+	// (rather than finishing normally), return what we streamed so far
+	// instead of treating a slow-but-working generation as a failure.
+	if ctx.Err() != nil || scanner.Err() != nil {
+		if buf.Len() > 0 {
+			log.Printf("⚠️ Anthropic stream interrupted (ctx=%v, scan=%v), returning partial (%d bytes)", ctx.Err(), scanner.Err(), buf.Len())
+			return buf.String(), &usage, nil
+		}
+		if scanner.Err() != nil {
+			return "", nil, fmt.Errorf("Anthropic stream read error: %w", scanner.Err())
+		}
+		return "", nil, ctx.Err()
 	}
 
-	answer := parsed.Content[0].Text
+	if buf.Len() == 0 {
+		return "", nil, fmt.Errorf("Anthropic returned no content")
+	}
 
 	log.Printf("📊 Token Usage: input=%d, output=%d, total=%d",
-		parsed.Usage.InputTokens,
-		parsed.Usage.OutputTokens,
-		parsed.Usage.InputTokens+parsed.Usage.OutputTokens)
+		usage.InputTokens, usage.OutputTokens, usage.InputTokens+usage.OutputTokens)
 
-	log.Printf("📥 Response Preview:")
-	respPreview := answer
+	respPreview := buf.String()
 	if len(respPreview) > 300 {
 		respPreview = respPreview[:300] + "...(truncated)"
 	}
-	log.Printf("%s", respPreview)
-
+	log.Printf("📥 Response Preview:\n%s", respPreview)
 	log.Printf("🧪============== END ANTHROPIC CALL DEBUG ==============")
 
-	return answer, nil
+	return buf.String(), &usage, nil
 }
 
-func summarizerHandler(cfg Config) http.HandlerFunc {
+// summarizerHandler reads cfgStore on every request (rather than closing
+// over a fixed Config) so a config file edit picked up by watchConfigFile
+// takes effect on the next PR without restarting the service. The file
+// named by llm.system_prompt_file is re-read only on such a reload (edits
+// to it alone are not watched), and the Anthropic rate limiter keeps the
+// limits it was first built with.
+func summarizerHandler(cfgStore *atomic.Value) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgStore.Load().(Config)
 
 		raw, _ := io.ReadAll(r.Body)
 		log.Printf("📥 Summarizer received:\n%s\n", string(raw))
@@ -212,35 +426,35 @@ func summarizerHandler(cfg Config) http.HandlerFunc {
 			return
 		}
 
-		// Build security block
+		// Build security block: one "=== <name> Findings ===" section and
+		// severity summary per analyzer report, in the order the
+		// orchestrator's scanners ran.
 		securityBlock := ""
-		if strings.TrimSpace(req.SemgrepMarkdown) != "" {
-			securityBlock += "\n=== Semgrep Findings ===\n" + req.SemgrepMarkdown + "\n"
-		}
-
-		if strings.TrimSpace(req.SonarQubeMarkdown) != "" {
-			securityBlock += "\n=== SonarQube Findings ===\n" + req.SonarQubeMarkdown + "\n"
-		}
-
-		if securityBlock == "" {
-			securityBlock = "\n=== Security Analysis ===\n(No static analysis provided)\n"
-		}
-
-		// Add severity summary section
-		severityBlock := fmt.Sprintf(`
-=== Semgrep Severity Summary ===
+		severityBlock := ""
+		for _, rep := range req.AnalyzerReports {
+			if strings.TrimSpace(rep.Markdown) != "" {
+				securityBlock += fmt.Sprintf("\n=== %s Findings ===\n%s\n", rep.Name, rep.Markdown)
+			}
+			severityBlock += fmt.Sprintf(`
+=== %s Severity Summary ===
 - Blocker: %d
 - Critical: %d
 - Major: %d
 - Minor: %d
 - Info: %d
 `,
-			req.SemgrepSeverity.Blocker,
-			req.SemgrepSeverity.Critical,
-			req.SemgrepSeverity.Major,
-			req.SemgrepSeverity.Minor,
-			req.SemgrepSeverity.Info,
-		)
+				rep.Name,
+				rep.Severity.Blocker,
+				rep.Severity.Critical,
+				rep.Severity.Major,
+				rep.Severity.Minor,
+				rep.Severity.Info,
+			)
+		}
+
+		if securityBlock == "" {
+			securityBlock = "\n=== Security Analysis ===\n(No static analysis provided)\n"
+		}
 
 		// Merge into final user prompt
 		userPrompt := fmt.Sprintf(`
@@ -258,7 +472,7 @@ URL: %s
 %s
 
 Task:
-Integrate description, review, Semgrep, and severity into a single PR comment using the required markdown format.
+Integrate description, review, and each analyzer's findings and severity into a single PR comment using the required markdown format.
 `,
 			req.PR.PRNumber,
 			req.PR.Title,
@@ -276,13 +490,14 @@ Integrate description, review, Semgrep, and severity into a single PR comment us
 		ctx, cancel := context.WithTimeout(r.Context(), 120*time.Second)
 		defer cancel()
 
-		md, err := callLLM(ctx, cfg, cfg.SummarizerPrompt, userPrompt)
+		target := streamTarget{runID: req.RunID, progressURL: req.ProgressURL}
+		md, usage, err := callLLM(ctx, cfg, cfg.SummarizerPrompt, userPrompt, target)
 		if err != nil {
 			http.Error(w, err.Error(), 500)
 			return
 		}
 
-		resp := SummarizerResponse{Markdown: md}
+		resp := SummarizerResponse{Markdown: md, Usage: usage}
 
 		log.Printf("====================== FINAL MERGED MARKDOWN ======================")
 		prev := md
@@ -303,14 +518,21 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
-	cfg := loadConfig()
+	flag.Parse()
+
+	cfg, v := loadConfig()
 
 	if cfg.AnthropicKey == "" {
 		log.Printf("⚠️ Warning: ANTHROPIC_API_KEY is empty — summarizer will fail.")
 	}
 
-	http.HandleFunc("/post", summarizerHandler(cfg))
+	var cfgStore atomic.Value
+	cfgStore.Store(cfg)
+	watchConfigFile(v, &cfgStore)
+
+	http.HandleFunc("/post", summarizerHandler(&cfgStore))
 	http.HandleFunc("/health", healthHandler)
+	http.Handle("/metrics", promhttp.Handler())
 
 	log.Printf("🚀 Summarizer Agent running on %s (model=%s)", cfg.ListenAddr, cfg.Model)
 	if err := http.ListenAndServe(cfg.ListenAddr, nil); err != nil {
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+/* =====================================================================================
+   RESILIENT ANTHROPIC CALLS
+
+   callLLM used to fail hard on the first non-2xx from api.anthropic.com.
+   This wraps that call with the same shape of resilience the orchestrator
+   applies to its egress (orchestrator/internal/httpx): classified errors,
+   exponential backoff honoring Retry-After, a circuit breaker, and a
+   token-bucket limiter sized from config so a burst of PRs doesn't itself
+   trip Anthropic's rate limit.
+===================================================================================== */
+
+// errorClass mirrors orchestrator/internal/httpx.ErrorClass; duplicated
+// rather than shared because the summarizer and orchestrator are built as
+// independent binaries with no common module.
+type errorClass string
+
+const (
+	classTransient   errorClass = "transient"
+	classRateLimited errorClass = "rate_limited"
+	classAuth        errorClass = "auth"
+	classPermanent   errorClass = "permanent"
+)
+
+func (c errorClass) retryable() bool {
+	return c == classTransient || c == classRateLimited
+}
+
+type classifiedError struct {
+	class  errorClass
+	status int
+	detail string
+}
+
+func (e *classifiedError) Error() string {
+	if e.detail != "" {
+		return fmt.Sprintf("anthropic: %s (status %d): %s", e.class, e.status, e.detail)
+	}
+	return fmt.Sprintf("anthropic: %s (status %d)", e.class, e.status)
+}
+
+// anthropicErrorEnvelope mirrors the {"type":"error","error":{"type":...}}
+// body Anthropic returns on non-2xx responses.
+type anthropicErrorEnvelope struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func classifyAnthropic(status int, body []byte) *classifiedError {
+	var envelope anthropicErrorEnvelope
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error.Type != "" {
+		switch envelope.Error.Type {
+		case "rate_limit_error":
+			return &classifiedError{class: classRateLimited, status: status, detail: envelope.Error.Message}
+		case "authentication_error", "permission_error":
+			return &classifiedError{class: classAuth, status: status, detail: envelope.Error.Message}
+		case "invalid_request_error", "not_found_error", "request_too_large":
+			return &classifiedError{class: classPermanent, status: status, detail: envelope.Error.Message}
+		case "overloaded_error", "api_error", "timeout_error":
+			return &classifiedError{class: classTransient, status: status, detail: envelope.Error.Message}
+		}
+	}
+
+	switch {
+	case status == http.StatusTooManyRequests:
+		return &classifiedError{class: classRateLimited, status: status}
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return &classifiedError{class: classAuth, status: status}
+	case status == 529 || status >= 500:
+		return &classifiedError{class: classTransient, status: status}
+	default:
+		return &classifiedError{class: classPermanent, status: status}
+	}
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	delta := time.Duration(rand.Int63n(int64(d)/5 + 1))
+	if rand.Intn(2) == 0 {
+		return d - delta
+	}
+	return d + delta
+}
+
+// tokenBucket is a minimal rate limiter sized from cfg.RateLimitRPS /
+// cfg.RateLimitBurst: just enough to keep a burst of PRs from tripping
+// Anthropic's own rate limit before Anthropic's 429 ever gets a chance to.
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	capacity   float64
+	refillRate float64 // tokens per second
+	last       time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if rps <= 0 {
+		rps = 2
+	}
+	if burst <= 0 {
+		burst = 4
+	}
+	return &tokenBucket{tokens: float64(burst), capacity: float64(burst), refillRate: rps, last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.refillRate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		deficit := 1 - b.tokens
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(deficit / b.refillRate * float64(time.Second))):
+		}
+	}
+}
+
+// circuitBreaker is a small consecutive-failure breaker, matching
+// orchestrator/internal/httpx's shape without pulling in sony/gobreaker
+// for a single endpoint.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	open      bool
+	openedAt  time.Time
+	failures  int
+	openAfter int
+	cooldown  time.Duration
+}
+
+func newCircuitBreaker(openAfter int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{openAfter: openAfter, cooldown: cooldown}
+}
+
+var errCircuitOpen = errors.New("anthropic: circuit breaker open")
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true
+	}
+	if time.Since(b.openedAt) >= b.cooldown {
+		// half-open: let one probe through. Restarting the cooldown holds
+		// other callers back until the probe succeeds or the cooldown ends.
+		b.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.open = false
+}
+
+func (b *circuitBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.openAfter {
+		b.open = true
+		b.openedAt = time.Now()
+	}
+}
+
+var (
+	anthropicRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "summarizer_anthropic_requests_total",
+		Help: "Calls to the Anthropic API, by outcome (success, transient_error, rate_limited_error, auth_error, permanent_error, circuit_open).",
+	}, []string{"outcome"})
+
+	anthropicRequestDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "summarizer_anthropic_request_duration_seconds",
+		Help: "Latency of doAnthropicRequest up to the response headers, including retries; reading the stream is not included.",
+	})
+)
+
+// anthropicLimiter and anthropicBreaker are process-wide: there's exactly
+// one downstream endpoint (api.anthropic.com), so unlike orchestrator's
+// per-host httpx.Client there's nothing to key them by.
+var (
+	anthropicLimiter *tokenBucket
+	anthropicOnce    sync.Once
+	anthropicBreaker = newCircuitBreaker(5, 30*time.Second)
+)
+
+func limiterFor(cfg Config) *tokenBucket {
+	anthropicOnce.Do(func() {
+		anthropicLimiter = newTokenBucket(cfg.RateLimitRPS, cfg.RateLimitBurst)
+	})
+	return anthropicLimiter
+}
+
+// doAnthropicRequest executes newReq with the token bucket, circuit
+// breaker, and exponential-backoff retry applied. newReq must build a
+// fresh request and body on every call.
+func doAnthropicRequest(ctx context.Context, cfg Config, newReq func() (*http.Request, error)) (*http.Response, error) {
+	start := time.Now()
+	limiter := limiterFor(cfg)
+
+	const maxElapsed = 2 * time.Minute
+	deadline := start.Add(maxElapsed)
+	backoff := 500 * time.Millisecond
+
+	for attempt := 1; ; attempt++ {
+		if !anthropicBreaker.allow() {
+			anthropicRequestsTotal.WithLabelValues("circuit_open").Inc()
+			anthropicRequestDuration.Observe(time.Since(start).Seconds())
+			return nil, errCircuitOpen
+		}
+
+		if err := limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			anthropicBreaker.recordFailure()
+			if time.Now().Add(backoff).After(deadline) {
+				anthropicRequestsTotal.WithLabelValues("transient_error").Inc()
+				anthropicRequestDuration.Observe(time.Since(start).Seconds())
+				return nil, err
+			}
+			log.Printf("⚠️ Anthropic network error (attempt %d), retrying: %v", attempt, err)
+			if waitErr := sleepOrDone(ctx, jitter(backoff)); waitErr != nil {
+				return nil, waitErr
+			}
+			backoff *= 2
+			continue
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			anthropicBreaker.recordSuccess()
+			anthropicRequestsTotal.WithLabelValues("success").Inc()
+			anthropicRequestDuration.Observe(time.Since(start).Seconds())
+			return resp, nil
+		}
+
+		body, _ := readAllAndClose(resp)
+		classified := classifyAnthropic(resp.StatusCode, body)
+		anthropicBreaker.recordFailure()
+
+		if !classified.class.retryable() {
+			anthropicRequestsTotal.WithLabelValues(string(classified.class) + "_error").Inc()
+			anthropicRequestDuration.Observe(time.Since(start).Seconds())
+			return nil, classified
+		}
+
+		wait := backoff
+		if ra := retryAfter(resp); ra > 0 {
+			wait = ra
+		}
+		if time.Now().Add(wait).After(deadline) {
+			anthropicRequestsTotal.WithLabelValues(string(classified.class) + "_error").Inc()
+			anthropicRequestDuration.Observe(time.Since(start).Seconds())
+			return nil, classified
+		}
+
+		log.Printf("⚠️ Anthropic %s (attempt %d), retrying: %v", classified.class, attempt, classified)
+		if waitErr := sleepOrDone(ctx, jitter(wait)); waitErr != nil {
+			return nil, waitErr
+		}
+		backoff *= 2
+	}
+}
+
+func sleepOrDone(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func readAllAndClose(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	buf := make([]byte, 0, 4096)
+	tmp := make([]byte, 4096)
+	for {
+		n, err := resp.Body.Read(tmp)
+		buf = append(buf, tmp[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	return buf, nil
+}